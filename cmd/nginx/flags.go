@@ -31,10 +31,12 @@ import (
 	"os"
 	"time"
 
+	"github.com/golang/glog"
 	"github.com/spf13/pflag"
 
 	apiv1 "k8s.io/api/core/v1"
 
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/class"
 	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
 	"github.com/stolostron/management-ingress/pkg/ingress/controller"
 	ngx_config "github.com/stolostron/management-ingress/pkg/ingress/controller/config"
@@ -57,6 +59,24 @@ func parseFlags() (bool, *controller.Configuration, error) {
 		httpPort  = flags.Int("http-port", 8080, `Indicates the port to use for HTTP traffic`)
 		httpsPort = flags.Int("https-port", 8443, `Indicates the port to use for HTTPS traffic`)
 
+		statusPort = flags.Int("status-port", 10246, `Indicates the port NGINX uses to expose the stub_status
+		information to the ingress controller. It only listens on 127.0.0.1`)
+
+		metricsPort = flags.Int("metrics-port", 10254, `Indicates the port to use for the Prometheus metrics
+		endpoint (/metrics). Set to 0 to disable the metrics`)
+
+		dynamicConfiguration = flags.Bool("enable-dynamic-configuration", false, `Updates the endpoints of the
+		upstreams using a Lua balancer instead of reloading NGINX. Only the changes of the servers and
+		locations require a reload`)
+
+		lastGoodConfig = flags.String("last-good-configuration", "", `Path of the file where the last
+		NGINX configuration accepted by NGINX is saved. When the file exists and is valid NGINX starts with it.
+		The file is not saved if the flag is not provided`)
+
+		hostOwnership = flags.String("host-ownership", "", `Policy that restricts the namespaces that can
+		use a host, with the format <host>=<namespace>,<host>=<namespace>. The hosts can be wildcard
+		domains like *.example.com. The key host-ownership of the configuration ConfigMap takes precedence`)
+
 		showVersion = flags.Bool("version", false,
 			`Shows release information about the NGINX Ingress controller`)
 
@@ -66,6 +86,13 @@ func parseFlags() (bool, *controller.Configuration, error) {
 		watchNamespace = flags.String("watch-namespace", apiv1.NamespaceAll,
 			`Namespace to watch for Ingress. Default is to watch all namespaces`)
 
+		ingressClass = flags.String("ingress-class", class.DefaultClass,
+			`Name of the ingress class this controller satisfies. The class of an Ingress
+		is defined using the annotation "kubernetes.io/ingress.class" or the field spec.ingressClassName`)
+
+		controllerClass = flags.String("controller-class", class.ControllerClass,
+			`Value of the field spec.controller of the IngressClass resources handled by this controller`)
+
 		annotationsPrefix = flags.String("annotations-prefix", "ingress.open-cluster-management.io", `Prefix of the ingress annotations.`)
 
 		syncRateLimit = flags.Float32("sync-rate-limit", 0.3,
@@ -79,6 +106,14 @@ func parseFlags() (bool, *controller.Configuration, error) {
 		ingress controller should update the Ingress status IP/hostname. Default is true`)
 
 		electionID = flags.String("election-id", "ingress-controller-leader", `Election id to use for status update.`)
+
+		validationWebhook = flags.String("validating-webhook", "",
+			`The address to start an admission controller on to validate incoming ingresses.
+		Takes the form "<host>:port". If not provided, no admission controller is started.`)
+		validationWebhookCert = flags.String("validating-webhook-certificate", "",
+			`The path of the validating webhook certificate PEM.`)
+		validationWebhookKey = flags.String("validating-webhook-key", "",
+			`The path of the validating webhook key PEM.`)
 	)
 
 	if err := flag.Set("logtostderr", "true"); err != nil {
@@ -106,6 +141,21 @@ func parseFlags() (bool, *controller.Configuration, error) {
 
 	parser.AnnotationsPrefix = *annotationsPrefix
 
+	if *ingressClass != "" {
+		glog.Infof("Watching for Ingress class: %v", *ingressClass)
+
+		if *ingressClass != class.DefaultClass {
+			glog.Warningf("Only Ingress with class \"%v\" will be processed by this ingress controller", *ingressClass)
+		}
+
+		class.IngressClass = *ingressClass
+	}
+
+	if *controllerClass == "" {
+		return false, nil, fmt.Errorf("the flag --controller-class cannot be empty")
+	}
+	class.ControllerClass = *controllerClass
+
 	// check port collisions
 	if !ing_net.IsPortAvailable(*httpPort) {
 		return false, nil, fmt.Errorf("Port %v is already in use. Please check the flag --http-port", *httpPort)
@@ -115,19 +165,43 @@ func parseFlags() (bool, *controller.Configuration, error) {
 		return false, nil, fmt.Errorf("Port %v is already in use. Please check the flag --https-port", *httpsPort)
 	}
 
+	if !ing_net.IsPortAvailable(*statusPort) {
+		return false, nil, fmt.Errorf("Port %v is already in use. Please check the flag --status-port", *statusPort)
+	}
+
+	if *metricsPort < 0 {
+		return false, nil, fmt.Errorf("the flag --metrics-port cannot be negative")
+	}
+
+	if *metricsPort > 0 && !ing_net.IsPortAvailable(*metricsPort) {
+		return false, nil, fmt.Errorf("Port %v is already in use. Please check the flag --metrics-port", *metricsPort)
+	}
+
+	if *validationWebhook != "" && (*validationWebhookCert == "" || *validationWebhookKey == "") {
+		return false, nil, fmt.Errorf("the flags --validating-webhook-certificate and --validating-webhook-key are required with --validating-webhook")
+	}
+
 	config := &controller.Configuration{
-		APIServerHost:         *apiserverHost,
-		KubeConfigFile:        *kubeConfigFile,
-		UpdateStatus:          *updateStatus,
-		ElectionID:            *electionID,
-		ResyncPeriod:          *resyncPeriod,
-		Namespace:             *watchNamespace,
-		ConfigMapName:         *configMap,
-		SyncRateLimit:         *syncRateLimit,
-		DefaultSSLCertificate: *defSSLCertificate,
+		APIServerHost:             *apiserverHost,
+		KubeConfigFile:            *kubeConfigFile,
+		UpdateStatus:              *updateStatus,
+		ElectionID:                *electionID,
+		ResyncPeriod:              *resyncPeriod,
+		Namespace:                 *watchNamespace,
+		ConfigMapName:             *configMap,
+		SyncRateLimit:             *syncRateLimit,
+		DefaultSSLCertificate:     *defSSLCertificate,
+		MetricsPort:               *metricsPort,
+		DynamicConfiguration:      *dynamicConfiguration,
+		LastGoodConfigPath:        *lastGoodConfig,
+		HostOwnership:             *hostOwnership,
+		ValidationWebhook:         *validationWebhook,
+		ValidationWebhookCertPath: *validationWebhookCert,
+		ValidationWebhookKeyPath:  *validationWebhookKey,
 		ListenPorts: &ngx_config.ListenPorts{
-			HTTP:  *httpPort,
-			HTTPS: *httpsPort,
+			HTTP:   *httpPort,
+			HTTPS:  *httpsPort,
+			Status: *statusPort,
 		},
 	}
 
@@ -33,6 +33,13 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == renderCommand {
+		if err := render(os.Args[2:], os.Stdout); err != nil {
+			glog.Fatal(err)
+		}
+		os.Exit(0)
+	}
+
 	fmt.Println(version.String())
 
 	showVersion, conf, err := parseFlags()
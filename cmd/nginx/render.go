@@ -0,0 +1,230 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/spf13/pflag"
+
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/stolostron/management-ingress/pkg/file"
+	"github.com/stolostron/management-ingress/pkg/ingress"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/class"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/controller"
+	ngx_config "github.com/stolostron/management-ingress/pkg/ingress/controller/config"
+	ngx_template "github.com/stolostron/management-ingress/pkg/ingress/controller/template"
+)
+
+const renderCommand = "render"
+
+// render prints the NGINX configuration generated from the Kubernetes
+// manifests located in the paths passed as arguments (files or directories)
+// without a connection to a Kubernetes cluster.
+func render(args []string, out io.Writer) error {
+	var (
+		flags = pflag.NewFlagSet(renderCommand, pflag.ContinueOnError)
+
+		test = flags.Bool("test", false, `Validates the rendered configuration running "nginx -t"`)
+
+		tmplFile = flags.String("template", "/opt/ibm/router/nginx/template/nginx.tmpl",
+			`Path of the NGINX template used to render the configuration`)
+
+		configMap = flags.String("configmap", "",
+			`Name of the ConfigMap that contains the custom configuration to use`)
+
+		ingressClass = flags.String("ingress-class", class.DefaultClass,
+			`Name of the ingress class the rendered Ingress rules must satisfy`)
+
+		controllerClass = flags.String("controller-class", class.ControllerClass,
+			`Value of the field spec.controller of the IngressClass resources handled by the controller`)
+
+		annotationsPrefix = flags.String("annotations-prefix", "ingress.open-cluster-management.io", `Prefix of the ingress annotations.`)
+
+		defSSLCertificate = flags.String("default-ssl-certificate", "kube-system/router-certs", `Name of the secret
+		that contains a SSL certificate to be used as default for a HTTPS catch-all server.
+		Takes the form <namespace>/<secret name>.`)
+
+		sslDirectory = flags.String("ssl-directory", "", `Directory where the certificates of the
+		secrets are written. A temporal directory is used when it is not specified`)
+
+		dynamicConfiguration = flags.Bool("enable-dynamic-configuration", false,
+			`Renders the upstreams using the Lua balancer that receives the endpoints from the controller`)
+
+		httpPort   = flags.Int("http-port", 8080, `Indicates the port to use for HTTP traffic`)
+		httpsPort  = flags.Int("https-port", 8443, `Indicates the port to use for HTTPS traffic`)
+		statusPort = flags.Int("status-port", 10246, `Indicates the port NGINX uses to expose the stub_status information`)
+	)
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %v %v [flags] PATH...\n", filepath.Base(os.Args[0]), renderCommand)
+		flags.PrintDefaults()
+	}
+
+	flags.AddGoFlagSet(flag.CommandLine)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := flag.Set("logtostderr", "true"); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("at least one file or directory with Kubernetes manifests is required")
+	}
+
+	parser.AnnotationsPrefix = *annotationsPrefix
+	class.IngressClass = *ingressClass
+	class.ControllerClass = *controllerClass
+
+	if *sslDirectory == "" {
+		dir, err := ioutil.TempDir("", "ssl")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		*sslDirectory = dir
+	}
+	ingress.DefaultSSLDirectory = *sslDirectory
+
+	var objects []runtime.Object
+	for _, path := range flags.Args() {
+		objs, err := loadManifests(path)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, objs...)
+	}
+
+	tmpl, err := ngx_template.NewTemplate(*tmplFile, &file.DefaultFs{})
+	if err != nil {
+		return err
+	}
+
+	config := &controller.Configuration{
+		ConfigMapName:         *configMap,
+		DefaultSSLCertificate: *defSSLCertificate,
+		DynamicConfiguration:  *dynamicConfiguration,
+		ListenPorts: &ngx_config.ListenPorts{
+			HTTP:   *httpPort,
+			HTTPS:  *httpsPort,
+			Status: *statusPort,
+		},
+	}
+
+	content, err := controller.Render(config, objects, tmpl, *test)
+	if err != nil {
+		return err
+	}
+
+	_, err = out.Write(content)
+	return err
+}
+
+// loadManifests decodes the Kubernetes objects defined in a YAML or JSON file.
+// If the path is a directory all the files with the extensions .yaml, .yml
+// or .json are loaded (subdirectories are not traversed)
+func loadManifests(path string) ([]runtime.Object, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{path}
+	if fi.IsDir() {
+		entries, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+
+		files = []string{}
+		for _, entry := range entries {
+			switch strings.ToLower(filepath.Ext(entry.Name())) {
+			case ".yaml", ".yml", ".json":
+				if !entry.IsDir() {
+					files = append(files, filepath.Join(path, entry.Name()))
+				}
+			}
+		}
+	}
+
+	var objects []runtime.Object
+	for _, f := range files {
+		objs, err := decodeManifest(f)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected error reading %v: %v", f, err)
+		}
+		objects = append(objects, objs...)
+	}
+
+	return objects, nil
+}
+
+// decodeManifest decodes all the documents of a YAML or JSON file
+func decodeManifest(path string) ([]runtime.Object, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	// #nosec
+	defer f.Close()
+
+	var objects []runtime.Object
+	decoder := yaml.NewYAMLOrJSONDecoder(f, 4096)
+	for {
+		var raw runtime.RawExtension
+		err := decoder.Decode(&raw)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		raw.Raw = []byte(strings.TrimSpace(string(raw.Raw)))
+		if len(raw.Raw) == 0 || string(raw.Raw) == "null" {
+			continue
+		}
+
+		obj, gvk, err := scheme.Codecs.UniversalDeserializer().Decode(raw.Raw, nil, nil)
+		if runtime.IsNotRegisteredError(err) {
+			glog.Warningf("ignoring object of unknown kind in %v: %v", path, err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// namespaced objects without namespace are created in the default namespace
+		if _, ok := obj.(*networking.IngressClass); !ok {
+			m, err := meta.Accessor(obj)
+			if err != nil {
+				return nil, fmt.Errorf("unexpected object %v: %v", gvk, err)
+			}
+			if m.GetNamespace() == "" {
+				m.SetNamespace(apiv1.NamespaceDefault)
+			}
+		}
+
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
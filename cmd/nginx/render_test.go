@@ -0,0 +1,97 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files of the render command")
+
+const (
+	renderTemplate = "../../rootfs/opt/ibm/router/nginx/template/nginx.tmpl"
+	renderTestdata = "testdata/render"
+)
+
+// normalizeConfig removes the empty lines and trailing spaces so the
+// comparison does not depend on the availability of clean-nginx-conf.sh
+func normalizeConfig(data []byte) string {
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func TestRenderExamples(t *testing.T) {
+	examples, err := filepath.Glob("../../examples/*.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error listing examples: %v", err)
+	}
+	if len(examples) == 0 {
+		t.Fatalf("expected examples but none were found")
+	}
+
+	for _, example := range examples {
+		name := strings.TrimSuffix(filepath.Base(example), filepath.Ext(example))
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			args := []string{
+				"--template", renderTemplate,
+				"--ssl-directory", t.TempDir(),
+				example,
+				filepath.Join(renderTestdata, "services.yaml"),
+			}
+			if err := render(args, &out); err != nil {
+				t.Fatalf("unexpected error rendering %v: %v", example, err)
+			}
+
+			content := normalizeConfig(out.Bytes())
+			golden := filepath.Join(renderTestdata, name+".conf")
+			if *update {
+				if err := ioutil.WriteFile(golden, []byte(content), 0644); err != nil {
+					t.Fatalf("unexpected error updating %v: %v", golden, err)
+				}
+			}
+
+			expected, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("unexpected error reading %v: %v", golden, err)
+			}
+			if content != string(expected) {
+				t.Errorf("rendered configuration for %v does not match %v (run the test with -update to regenerate it)", example, golden)
+			}
+		})
+	}
+}
+
+func TestRenderWithoutManifests(t *testing.T) {
+	var out bytes.Buffer
+	if err := render([]string{"--template", renderTemplate}, &out); err == nil {
+		t.Errorf("expected an error rendering without manifests")
+	}
+}
+
+func TestLoadManifests(t *testing.T) {
+	objs, err := loadManifests(renderTestdata)
+	if err != nil {
+		t.Fatalf("unexpected error loading manifests: %v", err)
+	}
+	if len(objs) != 18 {
+		t.Errorf("expected 18 objects but returned %v", len(objs))
+	}
+
+	if _, err := loadManifests("testdata/does-not-exist"); err == nil {
+		t.Errorf("expected an error loading a path that does not exist")
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package admission
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/golang/glog"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+)
+
+var (
+	scheme = runtime.NewScheme()
+	codecs = serializer.NewCodecFactory(scheme)
+
+	ingressResource = metav1.GroupVersionResource{
+		Group:    networking.GroupName,
+		Version:  "v1",
+		Resource: "ingresses",
+	}
+)
+
+func init() {
+	if err := admissionv1.AddToScheme(scheme); err != nil {
+		glog.Fatalf("unexpected error registering admission types: %v", err)
+	}
+	if err := networking.AddToScheme(scheme); err != nil {
+		glog.Fatalf("unexpected error registering networking types: %v", err)
+	}
+}
+
+// Checker validates an Ingress before it is persisted
+type Checker interface {
+	// CheckIngress returns an error if the Ingress cannot be
+	// included in the NGINX configuration
+	CheckIngress(ing *networking.Ingress) error
+}
+
+// IngressAdmission handles the AdmissionReview requests of Ingress objects
+type IngressAdmission struct {
+	Checker Checker
+}
+
+// HandleAdmission populates the response of an AdmissionReview. Only the
+// creation and update of Ingress objects is validated, any other request
+// is allowed.
+func (ia *IngressAdmission) HandleAdmission(review *admissionv1.AdmissionReview) error {
+	if review.Request == nil {
+		return fmt.Errorf("admission review without request")
+	}
+
+	response := &admissionv1.AdmissionResponse{
+		UID:     review.Request.UID,
+		Allowed: true,
+	}
+	review.Response = response
+
+	if review.Request.Resource != ingressResource {
+		glog.V(3).Infof("allowing admission of resource %v", review.Request.Resource)
+		return nil
+	}
+
+	if review.Request.Operation != admissionv1.Create && review.Request.Operation != admissionv1.Update {
+		return nil
+	}
+
+	ing := &networking.Ingress{}
+	if _, _, err := codecs.UniversalDeserializer().Decode(review.Request.Object.Raw, nil, ing); err != nil {
+		glog.Errorf("unexpected error decoding ingress %v/%v: %v", review.Request.Namespace, review.Request.Name, err)
+		deny(response, err)
+		return nil
+	}
+
+	// the namespace is not set in the object when it is omitted in the request
+	if ing.Namespace == "" {
+		ing.Namespace = review.Request.Namespace
+	}
+
+	if err := ia.Checker.CheckIngress(ing); err != nil {
+		glog.Warningf("rejecting ingress %v/%v: %v", ing.Namespace, ing.Name, err)
+		deny(response, err)
+		return nil
+	}
+
+	glog.V(3).Infof("accepting ingress %v/%v", ing.Namespace, ing.Name)
+	return nil
+}
+
+func deny(response *admissionv1.AdmissionResponse, err error) {
+	response.Allowed = false
+	response.Result = &metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    http.StatusBadRequest,
+		Reason:  metav1.StatusReasonBadRequest,
+		Message: err.Error(),
+	}
+}
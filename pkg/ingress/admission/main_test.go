@@ -0,0 +1,149 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package admission
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const testUID = types.UID("b5e5f8e3-1c2a-4cf7-9a6a-5b2d6c0d6e41")
+
+type failChecker struct {
+	err error
+	ing *networking.Ingress
+}
+
+func (fc *failChecker) CheckIngress(ing *networking.Ingress) error {
+	fc.ing = ing
+	return fc.err
+}
+
+func buildReview(t *testing.T, resource metav1.GroupVersionResource, op admissionv1.Operation) *admissionv1.AdmissionReview {
+	ing := &networking.Ingress{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "networking.k8s.io/v1",
+			Kind:       "Ingress",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "foo",
+		},
+	}
+
+	raw, err := json.Marshal(ing)
+	if err != nil {
+		t.Fatalf("unexpected error encoding ingress: %v", err)
+	}
+
+	return &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "admission.k8s.io/v1",
+			Kind:       "AdmissionReview",
+		},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       testUID,
+			Resource:  resource,
+			Operation: op,
+			Namespace: "default",
+			Name:      "foo",
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+}
+
+func TestHandleAdmission(t *testing.T) {
+	otherResource := metav1.GroupVersionResource{Version: "v1", Resource: "services"}
+
+	testCases := []struct {
+		name     string
+		review   *admissionv1.AdmissionReview
+		err      error
+		allowed  bool
+		verified bool
+	}{
+		{"valid ingress", buildReview(t, ingressResource, admissionv1.Create), nil, true, true},
+		{"invalid ingress", buildReview(t, ingressResource, admissionv1.Create), fmt.Errorf("invalid"), false, true},
+		{"invalid ingress update", buildReview(t, ingressResource, admissionv1.Update), fmt.Errorf("invalid"), false, true},
+		{"ingress deletion", buildReview(t, ingressResource, admissionv1.Delete), fmt.Errorf("invalid"), true, false},
+		{"other resource", buildReview(t, otherResource, admissionv1.Create), fmt.Errorf("invalid"), true, false},
+	}
+
+	for _, tc := range testCases {
+		checker := &failChecker{err: tc.err}
+		ia := &IngressAdmission{Checker: checker}
+
+		if err := ia.HandleAdmission(tc.review); err != nil {
+			t.Fatalf("%v: unexpected error: %v", tc.name, err)
+		}
+
+		resp := tc.review.Response
+		if resp.UID != testUID {
+			t.Errorf("%v: expected uid %v but returned %v", tc.name, testUID, resp.UID)
+		}
+		if resp.Allowed != tc.allowed {
+			t.Errorf("%v: expected allowed %v but returned %v", tc.name, tc.allowed, resp.Allowed)
+		}
+		if !tc.allowed && resp.Result.Message != tc.err.Error() {
+			t.Errorf("%v: expected message %v but returned %v", tc.name, tc.err, resp.Result.Message)
+		}
+		if (checker.ing != nil) != tc.verified {
+			t.Errorf("%v: expected ingress verification %v", tc.name, tc.verified)
+		}
+		if checker.ing != nil && checker.ing.Namespace != "default" {
+			t.Errorf("%v: expected namespace of the request but returned %v", tc.name, checker.ing.Namespace)
+		}
+	}
+
+	if err := (&IngressAdmission{}).HandleAdmission(&admissionv1.AdmissionReview{}); err == nil {
+		t.Errorf("expected an error handling a review without request")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	h := &handler{&IngressAdmission{Checker: &failChecker{err: fmt.Errorf("invalid")}}}
+
+	body, err := json.Marshal(buildReview(t, ingressResource, admissionv1.Create))
+	if err != nil {
+		t.Fatalf("unexpected error encoding review: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %v but returned %v", http.StatusOK, w.Code)
+	}
+
+	review := &admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(w.Body.Bytes(), review); err != nil {
+		t.Fatalf("unexpected error decoding response: %v", err)
+	}
+	if review.APIVersion != "admission.k8s.io/v1" || review.Kind != "AdmissionReview" {
+		t.Errorf("expected an AdmissionReview response but returned %v", review.TypeMeta)
+	}
+	if review.Response == nil || review.Response.Allowed {
+		t.Errorf("expected a denied response but returned %v", review.Response)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte("{"))))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %v but returned %v", http.StatusBadRequest, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status %v but returned %v", http.StatusMethodNotAllowed, w.Code)
+	}
+}
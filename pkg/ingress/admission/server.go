@@ -0,0 +1,100 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package admission
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/golang/glog"
+
+	admissionv1 "k8s.io/api/admission/v1"
+)
+
+// Server exposes the validating webhook using HTTPS
+type Server struct {
+	server *http.Server
+
+	certFile string
+	keyFile  string
+}
+
+// NewServer creates a new validating webhook server listening in
+// the address passed as argument
+func NewServer(addr, certFile, keyFile string, checker Checker) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("/", &handler{&IngressAdmission{Checker: checker}})
+
+	return &Server{
+		server: &http.Server{
+			Addr:    addr,
+			Handler: mux,
+			TLSConfig: &tls.Config{
+				MinVersion: tls.VersionTLS12,
+			},
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+}
+
+// Start starts the HTTPS server. It blocks until the server is stopped
+func (s *Server) Start() {
+	glog.Infof("starting validating webhook in %v", s.server.Addr)
+	err := s.server.ListenAndServeTLS(s.certFile, s.keyFile)
+	if err != nil && err != http.ErrServerClosed {
+		glog.Fatalf("unexpected error starting validating webhook: %v", err)
+	}
+}
+
+// Stop shuts down the HTTPS server
+func (s *Server) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		glog.Warningf("unexpected error stopping validating webhook: %v", err)
+	}
+}
+
+// handler decodes the AdmissionReview requests sent by the API server
+type handler struct {
+	admission *IngressAdmission
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST requests are allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		glog.Errorf("unexpected error reading admission request: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	review := &admissionv1.AdmissionReview{}
+	if _, _, err := codecs.UniversalDeserializer().Decode(data, nil, review); err != nil {
+		glog.Errorf("unexpected error decoding admission request: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.admission.HandleAdmission(review); err != nil {
+		glog.Errorf("unexpected error handling admission request: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		glog.Errorf("unexpected error writing admission response: %v", err)
+	}
+}
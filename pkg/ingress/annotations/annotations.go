@@ -17,6 +17,8 @@ limitations under the License.
 package annotations
 
 import (
+	"sort"
+
 	"github.com/golang/glog"
 	"github.com/imdario/mergo"
 
@@ -24,14 +26,28 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/stolostron/management-ingress/pkg/ingress/annotations/auth"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/authreq"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/authtls"
 	"github.com/stolostron/management-ingress/pkg/ingress/annotations/authz"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/backendprotocol"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/canary"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/compression"
 	"github.com/stolostron/management-ingress/pkg/ingress/annotations/connection"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/customhttperrors"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/defaultbackend"
 	"github.com/stolostron/management-ingress/pkg/ingress/annotations/locationmodifier"
 	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
 	"github.com/stolostron/management-ingress/pkg/ingress/annotations/proxy"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/proxysetheaders"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/ratelimit"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/redirect"
 	"github.com/stolostron/management-ingress/pkg/ingress/annotations/rewrite"
 	"github.com/stolostron/management-ingress/pkg/ingress/annotations/secureupstream"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/securityheaders"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/sessionaffinity"
 	"github.com/stolostron/management-ingress/pkg/ingress/annotations/snippet"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/sourcerange"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/sslredirect"
 	"github.com/stolostron/management-ingress/pkg/ingress/annotations/upstreamhashby"
 	"github.com/stolostron/management-ingress/pkg/ingress/annotations/upstreamuri"
 	"github.com/stolostron/management-ingress/pkg/ingress/annotations/xforwardedprefix"
@@ -47,15 +63,29 @@ type Ingress struct {
 	metav1.ObjectMeta
 	AuthType             string
 	AuthzType            string
+	BackendProtocol      string
+	Canary               canary.Config
+	CertificateAuth      authtls.Config
+	Compression          compression.Config
 	ConfigurationSnippet string
+	CustomHTTPErrors     []int
+	DefaultBackend       defaultbackend.Config
+	ExternalAuth         authreq.Config
 	LocationModifier     string
+	ProxySetHeaders      string
 	UpstreamHashBy       string
 	UpstreamURI          string
+	Redirect             redirect.Config
 	Rewrite              rewrite.Config
 	SecureUpstream       secureupstream.Config
+	SecurityHeaders      securityheaders.Config
+	SessionAffinity      sessionaffinity.Config
+	SSLRedirect          sslredirect.Config
 	XForwardedPrefix     bool
 	Proxy                proxy.Config
 	Connection           connection.Config
+	RateLimit            ratelimit.Config
+	SourceRange          sourcerange.Config
 }
 
 // Extractor defines the annotation parsers to be used in the extraction of annotations
@@ -69,15 +99,29 @@ func NewAnnotationExtractor(cfg resolver.Resolver) Extractor {
 		map[string]parser.IngressAnnotation{
 			"AuthType":             auth.NewParser(cfg),
 			"AuthzType":            authz.NewParser(cfg),
+			"BackendProtocol":      backendprotocol.NewParser(cfg),
+			"Canary":               canary.NewParser(cfg),
+			"CertificateAuth":      authtls.NewParser(cfg),
+			"Compression":          compression.NewParser(cfg),
 			"ConfigurationSnippet": snippet.NewParser(cfg),
+			"CustomHTTPErrors":     customhttperrors.NewParser(cfg),
+			"DefaultBackend":       defaultbackend.NewParser(cfg),
+			"ExternalAuth":         authreq.NewParser(cfg),
 			"SecureUpstream":       secureupstream.NewParser(cfg),
+			"SecurityHeaders":      securityheaders.NewParser(cfg),
+			"SessionAffinity":      sessionaffinity.NewParser(cfg),
+			"SSLRedirect":          sslredirect.NewParser(cfg),
+			"Redirect":             redirect.NewParser(cfg),
 			"Rewrite":              rewrite.NewParser(cfg),
 			"UpstreamHashBy":       upstreamhashby.NewParser(cfg),
 			"XForwardedPrefix":     xforwardedprefix.NewParser(cfg),
 			"LocationModifier":     locationmodifier.NewParser(cfg),
 			"UpstreamURI":          upstreamuri.NewParser(cfg),
 			"Proxy":                proxy.NewParser(cfg),
+			"ProxySetHeaders":      proxysetheaders.NewParser(cfg),
 			"Connection":           connection.NewParser(cfg),
+			"RateLimit":            ratelimit.NewParser(cfg),
+			"SourceRange":          sourcerange.NewParser(cfg),
 		},
 	}
 }
@@ -123,3 +167,29 @@ func (e Extractor) Extract(ing *networking.Ingress) *Ingress {
 
 	return pia
 }
+
+// Validate runs all the annotation parsers against an Ingress and returns
+// the first error that indicates the content of an annotation is not valid
+// or that the location would be denied
+func (e Extractor) Validate(ing *networking.Ingress) error {
+	names := make([]string, 0, len(e.annotations))
+	for name := range e.annotations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		_, err := e.annotations[name].Parse(ing)
+		if err == nil {
+			continue
+		}
+
+		if errors.IsInvalidContent(err) || errors.IsLocationDenied(err) {
+			return err
+		}
+
+		glog.V(3).Infof("ignoring error reading %v annotation in Ingress %v/%v during validation: %v", name, ing.GetNamespace(), ing.GetName(), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package annotations
+
+import (
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+)
+
+func buildIngress(annotations map[string]string) *networking.Ingress {
+	return &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "foo",
+			Namespace:   api.NamespaceDefault,
+			Annotations: annotations,
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	ec := NewAnnotationExtractor(&resolver.Mock{})
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		valid       bool
+	}{
+		{"without annotations", nil, true},
+		{"valid annotations", map[string]string{
+			parser.GetAnnotationWithPrefix("auth-type"):         "id-token",
+			parser.GetAnnotationWithPrefix("location-modifier"): "~*",
+		}, true},
+		{"invalid auth type", map[string]string{
+			parser.GetAnnotationWithPrefix("auth-type"): "basic",
+		}, false},
+		{"invalid authz type", map[string]string{
+			parser.GetAnnotationWithPrefix("authz-type"): "abac",
+		}, false},
+		{"unknown location modifier", map[string]string{
+			parser.GetAnnotationWithPrefix("location-modifier"): "^~~",
+		}, false},
+		{"ca secret without secure backends", map[string]string{
+			parser.GetAnnotationWithPrefix("secure-verify-ca-secret"): "ca",
+		}, false},
+	}
+
+	for _, tc := range testCases {
+		err := ec.Validate(buildIngress(tc.annotations))
+		if tc.valid && err != nil {
+			t.Errorf("%v: expected a valid ingress but returned %v", tc.name, err)
+		}
+		if !tc.valid && !errors.IsInvalidContent(err) {
+			t.Errorf("%v: expected an invalid content error but returned %v", tc.name, err)
+		}
+	}
+}
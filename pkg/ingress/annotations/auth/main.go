@@ -12,11 +12,11 @@ restricted by GSA ADP Schedule Contract with IBM Corp.
 package auth
 
 import (
-	"github.com/pkg/errors"
 	networking "k8s.io/api/networking/v1"
 
 	"github.com/stolostron/management-ingress/pkg/ingress"
 	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
 	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
 )
 
@@ -32,9 +32,12 @@ func NewParser(r resolver.Resolver) parser.IngressAnnotation {
 // Parse parses the annotations contained in the ingress
 // rule used to indicate if the upstream servers should use SSL
 func (a at) Parse(ing *networking.Ingress) (interface{}, error) {
-	ca, _ := parser.GetStringAnnotation("auth-type", ing)
+	ca, err := parser.GetStringAnnotation("auth-type", ing)
+	if err != nil {
+		return "", err
+	}
 	if ca != ingress.IDToken && ca != ingress.AccessToken {
-		return "", errors.Errorf("Auth type %v is not supported", ca)
+		return "", errors.NewInvalidAnnotationContent("auth-type", ca)
 	}
 	return ca, nil
 }
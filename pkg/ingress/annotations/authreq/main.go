@@ -0,0 +1,168 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package authreq
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+)
+
+var (
+	methods       = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "CONNECT", "OPTIONS", "TRACE"}
+	headerRegex   = regexp.MustCompile(`^[a-zA-Z\d\-_]+$`)
+	cacheKeyRegex = regexp.MustCompile(`^[^"\;{}\s]+$`)
+)
+
+// Config returns the external authentication of the locations of an Ingress.
+// Each request is authorized with a subrequest to the URL, which must return
+// 2xx to continue, or 401 or 403 to reject the request.
+type Config struct {
+	URL string `json:"url"`
+	// Host is the host of the URL, sent in the Host header of the subrequest
+	Host   string `json:"host"`
+	Method string `json:"method,omitempty"`
+	// ResponseHeaders are the headers of the response of the subrequest
+	// copied to the request sent to the upstream
+	ResponseHeaders []string `json:"responseHeaders,omitempty"`
+	// SigninURL is the location where the clients are redirected
+	// when the authentication service returns 401
+	SigninURL string `json:"signinURL,omitempty"`
+	// CacheKey enables the cache of the responses of the subrequest
+	CacheKey string `json:"cacheKey,omitempty"`
+}
+
+type authReq struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new external authentication annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return authReq{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to authenticate the requests with an external service
+func (a authReq) Parse(ing *networking.Ingress) (interface{}, error) {
+	str, err := parser.GetStringAnnotation("auth-url", ing)
+	if err != nil {
+		return nil, err
+	}
+	if str == "" {
+		return nil, errors.ErrMissingAnnotations
+	}
+
+	authURL, err := parseURL(str)
+	if err != nil {
+		return nil, errors.NewInvalidAnnotationContent("auth-url", str)
+	}
+
+	method, _ := parser.GetStringAnnotation("auth-method", ing)
+	if method != "" && !validMethod(method) {
+		return nil, errors.NewInvalidAnnotationContent("auth-method", method)
+	}
+
+	signin, _ := parser.GetStringAnnotation("auth-signin", ing)
+	if signin != "" {
+		if _, err := parseURL(signin); err != nil {
+			return nil, errors.NewInvalidAnnotationContent("auth-signin", signin)
+		}
+	}
+
+	var headers []string
+	hstr, _ := parser.GetStringAnnotation("auth-response-headers", ing)
+	for _, header := range strings.Split(hstr, ",") {
+		header = strings.TrimSpace(header)
+		if header == "" {
+			continue
+		}
+		if !headerRegex.MatchString(header) {
+			return nil, errors.NewInvalidAnnotationContent("auth-response-headers", hstr)
+		}
+		headers = append(headers, header)
+	}
+
+	cacheKey, _ := parser.GetStringAnnotation("auth-cache-key", ing)
+	if cacheKey != "" && !cacheKeyRegex.MatchString(cacheKey) {
+		return nil, errors.NewInvalidAnnotationContent("auth-cache-key", cacheKey)
+	}
+
+	return &Config{
+		URL:             str,
+		Host:            authURL.Hostname(),
+		Method:          method,
+		ResponseHeaders: headers,
+		SigninURL:       signin,
+		CacheKey:        cacheKey,
+	}, nil
+}
+
+// parseURL parses an absolute http or https URL
+func parseURL(str string) (*url.URL, error) {
+	u, err := url.Parse(str)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.Errorf("%v is not a valid scheme", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, errors.Errorf("%v does not contain a host", str)
+	}
+	if strings.ContainsAny(str, "\"'; \t") {
+		return nil, errors.Errorf("%v contains invalid characters", str)
+	}
+	return u, nil
+}
+
+// validMethod returns true if the method is a valid HTTP method
+func validMethod(method string) bool {
+	for _, m := range methods {
+		if method == m {
+			return true
+		}
+	}
+	return false
+}
+
+// Equal tests for equality between two Config types
+func (e1 *Config) Equal(e2 *Config) bool {
+	if e1 == e2 {
+		return true
+	}
+	if e1 == nil || e2 == nil {
+		return false
+	}
+	if e1.URL != e2.URL {
+		return false
+	}
+	if e1.Host != e2.Host {
+		return false
+	}
+	if e1.Method != e2.Method {
+		return false
+	}
+	if e1.SigninURL != e2.SigninURL {
+		return false
+	}
+	if e1.CacheKey != e2.CacheKey {
+		return false
+	}
+	if len(e1.ResponseHeaders) != len(e2.ResponseHeaders) {
+		return false
+	}
+	for i := range e1.ResponseHeaders {
+		if e1.ResponseHeaders[i] != e2.ResponseHeaders[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package authreq
+
+import (
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+)
+
+func TestParse(t *testing.T) {
+	authURL := parser.GetAnnotationWithPrefix("auth-url")
+	method := parser.GetAnnotationWithPrefix("auth-method")
+	headers := parser.GetAnnotationWithPrefix("auth-response-headers")
+	signin := parser.GetAnnotationWithPrefix("auth-signin")
+	cacheKey := parser.GetAnnotationWithPrefix("auth-cache-key")
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo-bar",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    *Config
+		missing     bool
+		invalid     bool
+	}{
+		{"no annotations", nil, nil, true, false},
+		{"empty url", map[string]string{authURL: ""}, nil, true, false},
+		{"only url", map[string]string{authURL: "http://auth.default.svc:8080/verify"}, &Config{
+			URL:  "http://auth.default.svc:8080/verify",
+			Host: "auth.default.svc",
+		}, false, false},
+		{"all annotations", map[string]string{
+			authURL:  "https://auth.example.com/verify?scope=ui",
+			method:   "POST",
+			headers:  "X-Auth-User, X-Auth-Groups",
+			signin:   "https://auth.example.com/signin",
+			cacheKey: "$http_authorization",
+		}, &Config{
+			URL:             "https://auth.example.com/verify?scope=ui",
+			Host:            "auth.example.com",
+			Method:          "POST",
+			ResponseHeaders: []string{"X-Auth-User", "X-Auth-Groups"},
+			SigninURL:       "https://auth.example.com/signin",
+			CacheKey:        "$http_authorization",
+		}, false, false},
+		{"relative url", map[string]string{authURL: "/verify"}, nil, false, true},
+		{"invalid scheme", map[string]string{authURL: "ftp://auth.example.com"}, nil, false, true},
+		{"url with spaces", map[string]string{authURL: "http://auth.example.com/a; return 200"}, nil, false, true},
+		{"invalid method", map[string]string{authURL: "http://auth", method: "FETCH"}, nil, false, true},
+		{"invalid header", map[string]string{authURL: "http://auth", headers: "X-User;"}, nil, false, true},
+		{"invalid signin", map[string]string{authURL: "http://auth", signin: "signin"}, nil, false, true},
+		{"invalid cache key", map[string]string{authURL: "http://auth", cacheKey: "$a }"}, nil, false, true},
+	}
+
+	for _, tc := range testCases {
+		ing.SetAnnotations(tc.annotations)
+		result, err := ap.Parse(ing)
+		if tc.missing != errors.IsMissingAnnotations(err) {
+			t.Errorf("%v: expected a missing annotations error: %v, but returned %v", tc.name, tc.missing, err)
+		}
+		if tc.invalid != errors.IsInvalidContent(err) {
+			t.Errorf("%v: expected an invalid content error: %v, but returned %v", tc.name, tc.invalid, err)
+		}
+		if tc.expected == nil {
+			continue
+		}
+		if !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("%v: expected %+v but returned %+v", tc.name, tc.expected, result)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package authtls
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+	networking "k8s.io/api/networking/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	ing_errors "github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+	"github.com/stolostron/management-ingress/pkg/k8s"
+)
+
+const (
+	defaultVerifyClient = "on"
+	defaultDepth        = 1
+)
+
+var (
+	verifyClientRegex = regexp.MustCompile(`^(on|off|optional|optional_no_ca)$`)
+	errorPageRegex    = regexp.MustCompile(`^[^"'\;{}\s]+$`)
+)
+
+// Config contains the client certificate authentication of an Ingress.
+// The certificate authorities are read from the 'ca.crt' key of the secret.
+type Config struct {
+	resolver.AuthSSLCert
+	VerifyClient       string `json:"verifyClient"`
+	ValidationDepth    int    `json:"validationDepth"`
+	ErrorPage          string `json:"errorPage,omitempty"`
+	PassCertToUpstream bool   `json:"passCertToUpstream"`
+}
+
+type authTLS struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new client certificate authentication annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return authTLS{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to authenticate the clients with certificates
+func (a authTLS) Parse(ing *networking.Ingress) (interface{}, error) {
+	secret, err := parser.GetStringAnnotation("auth-tls-secret", ing)
+	if err != nil {
+		return nil, err
+	}
+	if secret == "" {
+		return nil, ing_errors.ErrMissingAnnotations
+	}
+
+	if _, _, err := k8s.ParseNameNS(secret); err != nil {
+		return nil, ing_errors.NewInvalidAnnotationContent("auth-tls-secret", secret)
+	}
+
+	verify, err := parser.GetStringAnnotation("auth-tls-verify-client", ing)
+	if err != nil || verify == "" {
+		verify = defaultVerifyClient
+	}
+	if !verifyClientRegex.MatchString(verify) {
+		return nil, ing_errors.NewInvalidAnnotationContent("auth-tls-verify-client", verify)
+	}
+
+	depth, err := parser.GetIntAnnotation("auth-tls-verify-depth", ing)
+	if ing_errors.IsMissingAnnotations(err) {
+		depth = defaultDepth
+	} else if err != nil || depth < 1 {
+		return nil, ing_errors.NewInvalidAnnotationContent("auth-tls-verify-depth", depth)
+	}
+
+	errorPage, _ := parser.GetStringAnnotation("auth-tls-error-page", ing)
+	if errorPage != "" && !errorPageRegex.MatchString(errorPage) {
+		return nil, ing_errors.NewInvalidAnnotationContent("auth-tls-error-page", errorPage)
+	}
+
+	passCert, _ := parser.GetBoolAnnotation("auth-tls-pass-certificate-to-upstream", ing)
+
+	authCert, err := a.r.GetAuthCertificate(secret)
+	if err != nil {
+		return nil, errors.Wrap(err, "error obtaining certificate")
+	}
+	if authCert == nil || authCert.CAFileName == "" {
+		return nil, ing_errors.NewInvalidAnnotationContent("auth-tls-secret", secret)
+	}
+
+	return &Config{
+		AuthSSLCert:        *authCert,
+		VerifyClient:       verify,
+		ValidationDepth:    depth,
+		ErrorPage:          errorPage,
+		PassCertToUpstream: passCert,
+	}, nil
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if !(&c1.AuthSSLCert).Equal(&c2.AuthSSLCert) {
+		return false
+	}
+	if c1.VerifyClient != c2.VerifyClient {
+		return false
+	}
+	if c1.ValidationDepth != c2.ValidationDepth {
+		return false
+	}
+	if c1.ErrorPage != c2.ErrorPage {
+		return false
+	}
+	if c1.PassCertToUpstream != c2.PassCertToUpstream {
+		return false
+	}
+
+	return true
+}
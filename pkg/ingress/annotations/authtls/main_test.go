@@ -0,0 +1,113 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package authtls
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+)
+
+type mockSecret struct {
+	resolver.Mock
+}
+
+func (m mockSecret) GetAuthCertificate(name string) (*resolver.AuthSSLCert, error) {
+	switch name {
+	case "default/ca":
+		return &resolver.AuthSSLCert{
+			Secret:     name,
+			CAFileName: "/ssl/default-ca.pem",
+			PemSHA:     "123",
+		}, nil
+	case "default/tls":
+		return &resolver.AuthSSLCert{Secret: name, PemFileName: "/ssl/default-tls.pem"}, nil
+	}
+	return nil, fmt.Errorf("secret %v does not exist", name)
+}
+
+func TestParse(t *testing.T) {
+	secret := parser.GetAnnotationWithPrefix("auth-tls-secret")
+	verify := parser.GetAnnotationWithPrefix("auth-tls-verify-client")
+	depth := parser.GetAnnotationWithPrefix("auth-tls-verify-depth")
+	errorPage := parser.GetAnnotationWithPrefix("auth-tls-error-page")
+	passCert := parser.GetAnnotationWithPrefix("auth-tls-pass-certificate-to-upstream")
+
+	ap := NewParser(mockSecret{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo-bar",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+	cert := resolver.AuthSSLCert{Secret: "default/ca", CAFileName: "/ssl/default-ca.pem", PemSHA: "123"}
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    *Config
+		missing     bool
+		invalid     bool
+	}{
+		{"no annotations", nil, nil, true, false},
+		{"only secret", map[string]string{secret: "default/ca"}, &Config{
+			AuthSSLCert:     cert,
+			VerifyClient:    "on",
+			ValidationDepth: 1,
+		}, false, false},
+		{"all annotations", map[string]string{
+			secret:    "default/ca",
+			verify:    "optional",
+			depth:     "3",
+			errorPage: "https://example.com/cert-error",
+			passCert:  "true",
+		}, &Config{
+			AuthSSLCert:        cert,
+			VerifyClient:       "optional",
+			ValidationDepth:    3,
+			ErrorPage:          "https://example.com/cert-error",
+			PassCertToUpstream: true,
+		}, false, false},
+		{"secret without namespace", map[string]string{secret: "ca"}, nil, false, true},
+		{"secret without ca.crt", map[string]string{secret: "default/tls"}, nil, false, true},
+		{"invalid verify client", map[string]string{secret: "default/ca", verify: "yes"}, nil, false, true},
+		{"invalid depth", map[string]string{secret: "default/ca", depth: "0"}, nil, false, true},
+		{"invalid error page", map[string]string{secret: "default/ca", errorPage: "/error; return 200"}, nil, false, true},
+	}
+
+	for _, tc := range testCases {
+		ing.SetAnnotations(tc.annotations)
+		result, err := ap.Parse(ing)
+		if tc.missing != errors.IsMissingAnnotations(err) {
+			t.Errorf("%v: expected a missing annotations error: %v, but returned %v", tc.name, tc.missing, err)
+		}
+		if tc.invalid != errors.IsInvalidContent(err) {
+			t.Errorf("%v: expected an invalid content error: %v, but returned %v", tc.name, tc.invalid, err)
+		}
+		if tc.expected == nil {
+			continue
+		}
+		if !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("%v: expected %+v but returned %+v", tc.name, tc.expected, result)
+		}
+	}
+
+	ing.SetAnnotations(map[string]string{secret: "default/missing"})
+	if _, err := ap.Parse(ing); err == nil {
+		t.Errorf("expected an error with a missing secret")
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package backendprotocol
+
+import (
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+)
+
+// Protocols used to reach the endpoints of a backend
+const (
+	HTTP  = "HTTP"
+	HTTPS = "HTTPS"
+	GRPC  = "GRPC"
+	GRPCS = "GRPCS"
+	// H2C is HTTP/2 without TLS
+	H2C = "H2C"
+)
+
+var validProtocols = []string{HTTP, HTTPS, GRPC, GRPCS, H2C}
+
+type backendProtocol struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new backend protocol annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return backendProtocol{r}
+}
+
+// Parse parses the annotations contained in the ingress rule used to
+// indicate the protocol of the endpoints. The annotation secure-backends
+// is the same as the protocol HTTPS.
+func (b backendProtocol) Parse(ing *networking.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation("backend-protocol", ing)
+	if err != nil || val == "" {
+		if secure, _ := parser.GetBoolAnnotation("secure-backends", ing); secure {
+			return HTTPS, nil
+		}
+		return nil, errors.ErrMissingAnnotations
+	}
+
+	proto := strings.ToUpper(strings.TrimSpace(val))
+	for _, valid := range validProtocols {
+		if proto == valid {
+			return proto, nil
+		}
+	}
+
+	return nil, errors.NewInvalidAnnotationContent("backend-protocol", val)
+}
+
+// IsSecure returns true if the protocol uses TLS
+func IsSecure(proto string) bool {
+	return proto == HTTPS || proto == GRPCS
+}
+
+// IsGRPC returns true if the endpoints are reached with the NGINX
+// gRPC module, which is also the only one that uses HTTP/2
+func IsGRPC(proto string) bool {
+	return proto == GRPC || proto == GRPCS || proto == H2C
+}
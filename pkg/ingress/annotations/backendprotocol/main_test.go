@@ -0,0 +1,69 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package backendprotocol
+
+import (
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+)
+
+func TestParse(t *testing.T) {
+	protocol := parser.GetAnnotationWithPrefix("backend-protocol")
+	secure := parser.GetAnnotationWithPrefix("secure-backends")
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo-bar",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    interface{}
+		missing     bool
+		invalid     bool
+	}{
+		{"no annotations", nil, nil, true, false},
+		{"empty annotation", map[string]string{protocol: ""}, nil, true, false},
+		{"http", map[string]string{protocol: "HTTP"}, HTTP, false, false},
+		{"lower case", map[string]string{protocol: "grpcs"}, GRPCS, false, false},
+		{"h2c", map[string]string{protocol: "H2C"}, H2C, false, false},
+		{"secure backends", map[string]string{secure: "true"}, HTTPS, false, false},
+		{"not secure backends", map[string]string{secure: "false"}, nil, true, false},
+		{"protocol over secure backends", map[string]string{protocol: "GRPC", secure: "true"}, GRPC, false, false},
+		{"invalid", map[string]string{protocol: "FCGI"}, nil, false, true},
+	}
+
+	for _, tc := range testCases {
+		ing.SetAnnotations(tc.annotations)
+		result, err := ap.Parse(ing)
+		if tc.missing != errors.IsMissingAnnotations(err) {
+			t.Errorf("%v: expected a missing annotations error: %v, but returned %v", tc.name, tc.missing, err)
+		}
+		if tc.invalid != errors.IsInvalidContent(err) {
+			t.Errorf("%v: expected an invalid content error: %v, but returned %v", tc.name, tc.invalid, err)
+		}
+		if tc.expected == nil {
+			continue
+		}
+		if result != tc.expected {
+			t.Errorf("%v: expected %v but returned %v", tc.name, tc.expected, result)
+		}
+	}
+}
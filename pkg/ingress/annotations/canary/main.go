@@ -0,0 +1,114 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package canary
+
+import (
+	"regexp"
+
+	networking "k8s.io/api/networking/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+)
+
+var (
+	headerRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+	cookieRegex = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+	// the value is rendered in a quoted string of the NGINX configuration
+	headerValueRegex = regexp.MustCompile(`^[^"\\;{}\s]+$`)
+)
+
+// Config returns the configuration of a canary Ingress. The backends of a
+// canary Ingress receive part of the traffic of the locations defined by
+// other Ingress with the same host and path.
+type Config struct {
+	Enabled     bool   `json:"enabled"`
+	Weight      int    `json:"weight"`
+	Header      string `json:"header"`
+	HeaderValue string `json:"headerValue"`
+	Cookie      string `json:"cookie"`
+}
+
+type canary struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new canary annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return canary{r}
+}
+
+// Parse parses the annotations contained in the ingress rule used to indicate
+// if the Ingress is a canary and how the traffic is routed to its backends
+func (c canary) Parse(ing *networking.Ingress) (interface{}, error) {
+	config := &Config{}
+
+	enabled, err := parser.GetBoolAnnotation("canary", ing)
+	if err != nil && !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+	config.Enabled = enabled
+
+	config.Weight, err = parser.GetIntAnnotation("canary-weight", ing)
+	if err != nil && !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+	if config.Weight < 0 || config.Weight > 100 {
+		return nil, errors.NewInvalidAnnotationContent("canary-weight", config.Weight)
+	}
+
+	config.Header, _ = parser.GetStringAnnotation("canary-by-header", ing)
+	if config.Header != "" && !headerRegex.MatchString(config.Header) {
+		return nil, errors.NewInvalidAnnotationContent("canary-by-header", config.Header)
+	}
+
+	config.HeaderValue, _ = parser.GetStringAnnotation("canary-by-header-value", ing)
+	if config.HeaderValue != "" && (config.Header == "" || !headerValueRegex.MatchString(config.HeaderValue)) {
+		return nil, errors.NewInvalidAnnotationContent("canary-by-header-value", config.HeaderValue)
+	}
+
+	config.Cookie, _ = parser.GetStringAnnotation("canary-by-cookie", ing)
+	if config.Cookie != "" && !cookieRegex.MatchString(config.Cookie) {
+		return nil, errors.NewInvalidAnnotationContent("canary-by-cookie", config.Cookie)
+	}
+
+	if !config.Enabled {
+		if config.Weight > 0 || config.Header != "" || config.Cookie != "" {
+			return nil, errors.InvalidContent{
+				Name: "the canary annotations require the annotation canary with the value true",
+			}
+		}
+		return nil, errors.ErrMissingAnnotations
+	}
+
+	return config, nil
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Enabled != c2.Enabled {
+		return false
+	}
+	if c1.Weight != c2.Weight {
+		return false
+	}
+	if c1.Header != c2.Header {
+		return false
+	}
+	if c1.HeaderValue != c2.HeaderValue {
+		return false
+	}
+	if c1.Cookie != c2.Cookie {
+		return false
+	}
+
+	return true
+}
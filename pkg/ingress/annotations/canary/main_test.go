@@ -0,0 +1,79 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package canary
+
+import (
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+)
+
+func TestParse(t *testing.T) {
+	canary := parser.GetAnnotationWithPrefix("canary")
+	weight := parser.GetAnnotationWithPrefix("canary-weight")
+	header := parser.GetAnnotationWithPrefix("canary-by-header")
+	headerValue := parser.GetAnnotationWithPrefix("canary-by-header-value")
+	cookie := parser.GetAnnotationWithPrefix("canary-by-cookie")
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    *Config
+		missing     bool
+		invalid     bool
+	}{
+		{"no annotations", nil, nil, true, false},
+		{"canary disabled", map[string]string{canary: "false"}, nil, true, false},
+		{"canary", map[string]string{canary: "true"}, &Config{Enabled: true}, false, false},
+		{"weight", map[string]string{canary: "true", weight: "20"}, &Config{Enabled: true, Weight: 20}, false, false},
+		{"header", map[string]string{canary: "true", header: "X-Canary", headerValue: "yes"},
+			&Config{Enabled: true, Header: "X-Canary", HeaderValue: "yes"}, false, false},
+		{"cookie", map[string]string{canary: "true", cookie: "canary"}, &Config{Enabled: true, Cookie: "canary"}, false, false},
+		{"invalid canary", map[string]string{canary: "maybe"}, nil, false, true},
+		{"invalid weight", map[string]string{canary: "true", weight: "101"}, nil, false, true},
+		{"weight not a number", map[string]string{canary: "true", weight: "half"}, nil, false, true},
+		{"invalid header", map[string]string{canary: "true", header: "X Canary"}, nil, false, true},
+		{"header value without header", map[string]string{canary: "true", headerValue: "yes"}, nil, false, true},
+		{"invalid header value", map[string]string{canary: "true", header: "X-Canary", headerValue: `yes"; return 200; "`}, nil, false, true},
+		{"invalid cookie", map[string]string{canary: "true", cookie: "can-ary"}, nil, false, true},
+		{"weight without canary", map[string]string{weight: "20"}, nil, false, true},
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+
+	for _, tc := range testCases {
+		ing.SetAnnotations(tc.annotations)
+		result, err := ap.Parse(ing)
+		if tc.missing != errors.IsMissingAnnotations(err) {
+			t.Errorf("%v: expected a missing annotations error: %v, but returned %v", tc.name, tc.missing, err)
+		}
+		if tc.invalid != errors.IsInvalidContent(err) {
+			t.Errorf("%v: expected an invalid content error: %v, but returned %v", tc.name, tc.invalid, err)
+		}
+		if tc.expected == nil {
+			continue
+		}
+		if !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("%v: expected %+v but returned %+v", tc.name, tc.expected, result)
+		}
+	}
+}
@@ -28,6 +28,8 @@ package class
 import (
 	"github.com/golang/glog"
 	networking "k8s.io/api/networking/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/store"
 )
 
 const (
@@ -35,6 +37,10 @@ const (
 	// The controller only processes Ingresses with this annotation either
 	// unset, or set to either the configured value or the empty string.
 	IngressKey = "kubernetes.io/ingress.class"
+
+	// IngressClassDefaultKey marks an IngressClass as the default one of the
+	// cluster. Ingresses without class are assigned to the default class.
+	IngressClassDefaultKey = "ingressclass.kubernetes.io/is-default-class"
 )
 
 var (
@@ -45,16 +51,63 @@ var (
 	// An empty string means accept all ingresses without
 	// annotation and the ones configured with class nginx
 	IngressClass = "ingress-open-cluster-management"
+
+	// ControllerClass defines the value of the field spec.controller of the
+	// IngressClass resources handled by the ingress controller
+	ControllerClass = "open-cluster-management.io/management-ingress"
+
+	// IngressClassLister contains the IngressClass resources of the cluster.
+	// When nil, spec.ingressClassName is matched against the class names.
+	IngressClassLister *store.IngressClassLister
 )
 
 // IsValid returns true if the given Ingress either doesn't specify
 // the ingress.class annotation, or it's set to the configured in the
 // ingress controller.
+// Ingresses without the annotation are checked using spec.ingressClassName
+// and the IngressClass it references, or the default IngressClass if the
+// Ingress does not contain a class.
 func IsValid(ing *networking.Ingress) bool {
 	ingress, ok := ing.GetAnnotations()[IngressKey]
-	if !ok {
-		glog.V(3).Infof("annotation %v is not present in ingress %v/%v", IngressKey, ing.Namespace, ing.Name)
+	if ok {
+		return ingress == IngressClass || ingress == DefaultClass
+	}
+
+	glog.V(3).Infof("annotation %v is not present in ingress %v/%v", IngressKey, ing.Namespace, ing.Name)
+
+	if ing.Spec.IngressClassName == nil || *ing.Spec.IngressClassName == "" {
+		return IngressClass == "" || DefaultClass == "" || isDefaultClassHandled()
+	}
+
+	className := *ing.Spec.IngressClassName
+	if IngressClassLister != nil {
+		ic, err := IngressClassLister.GetByName(className)
+		if err == nil {
+			return ic.Spec.Controller == ControllerClass
+		}
+		glog.V(3).Infof("%v, using the class name of ingress %v/%v", err, ing.Namespace, ing.Name)
+	}
+
+	return className == IngressClass || className == DefaultClass
+}
+
+// isDefaultClassHandled returns true if the IngressClass marked as default
+// in the cluster is handled by the ingress controller
+func isDefaultClassHandled() bool {
+	if IngressClassLister == nil {
+		return false
+	}
+
+	for _, obj := range IngressClassLister.List() {
+		ic := obj.(*networking.IngressClass)
+		if ic.GetAnnotations()[IngressClassDefaultKey] != "true" {
+			continue
+		}
+
+		if ic.Spec.Controller == ControllerClass {
+			return true
+		}
 	}
 
-	return ingress == IngressClass || ingress == DefaultClass
+	return false
 }
@@ -22,6 +22,9 @@ import (
 	api "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/store"
 )
 
 func TestIsValidClass(t *testing.T) {
@@ -68,3 +71,105 @@ func TestIsValidClass(t *testing.T) {
 		}
 	}
 }
+
+func buildIngressClass(name, controller string, isDefault bool) *networking.IngressClass {
+	ic := &networking.IngressClass{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: name,
+		},
+		Spec: networking.IngressClassSpec{
+			Controller: controller,
+		},
+	}
+	if isDefault {
+		ic.SetAnnotations(map[string]string{IngressClassDefaultKey: "true"})
+	}
+	return ic
+}
+
+func TestIsValidIngressClassName(t *testing.T) {
+	icl := IngressClassLister
+	// restore original values after the tests
+	defer func() {
+		IngressClassLister = icl
+	}()
+
+	lister := &store.IngressClassLister{Store: cache.NewStore(cache.MetaNamespaceKeyFunc)}
+	lister.Add(buildIngressClass("ours", ControllerClass, false))
+	lister.Add(buildIngressClass("theirs", "k8s.io/ingress-nginx", false))
+
+	tests := []struct {
+		className *string
+		lister    *store.IngressClassLister
+		isValid   bool
+	}{
+		{nil, nil, false},
+		{nil, lister, false},
+		{stringPtr(IngressClass), nil, true},
+		{stringPtr("ours"), nil, false},
+		{stringPtr("ours"), lister, true},
+		{stringPtr("theirs"), lister, false},
+		{stringPtr(IngressClass), lister, true},
+		{stringPtr("unknown"), lister, false},
+	}
+
+	for _, test := range tests {
+		ing := &networking.Ingress{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      "foo",
+				Namespace: api.NamespaceDefault,
+			},
+			Spec: networking.IngressSpec{
+				IngressClassName: test.className,
+			},
+		}
+
+		IngressClassLister = test.lister
+		b := IsValid(ing)
+		if b != test.isValid {
+			t.Errorf("test %v - expected %v but %v was returned", test, test.isValid, b)
+		}
+	}
+}
+
+func TestIsValidDefaultIngressClass(t *testing.T) {
+	icl := IngressClassLister
+	// restore original values after the tests
+	defer func() {
+		IngressClassLister = icl
+	}()
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	tests := []struct {
+		classes []*networking.IngressClass
+		isValid bool
+	}{
+		{[]*networking.IngressClass{}, false},
+		{[]*networking.IngressClass{buildIngressClass("ours", ControllerClass, false)}, false},
+		{[]*networking.IngressClass{buildIngressClass("theirs", "k8s.io/ingress-nginx", true)}, false},
+		{[]*networking.IngressClass{buildIngressClass("ours", ControllerClass, true)}, true},
+	}
+
+	for _, test := range tests {
+		lister := &store.IngressClassLister{Store: cache.NewStore(cache.MetaNamespaceKeyFunc)}
+		for _, ic := range test.classes {
+			lister.Add(ic)
+		}
+
+		IngressClassLister = lister
+		b := IsValid(ing)
+		if b != test.isValid {
+			t.Errorf("test %v - expected %v but %v was returned", test, test.isValid, b)
+		}
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package compression
+
+import (
+	"regexp"
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+)
+
+var mimeTypeRegex = regexp.MustCompile(`^(\*|[a-zA-Z0-9!#$&^_.+-]+/[a-zA-Z0-9!#$&^_.+*-]+)$`)
+
+// Config describes the compression of the responses of the locations of an
+// Ingress. Enabled is nil when the Ingress does not define the annotation,
+// and Types is empty, so the values of the configuration are used.
+type Config struct {
+	// Enabled enables or disables the gzip and brotli compression
+	Enabled *bool `json:"enabled,omitempty"`
+	// Types are the space separated MIME types of the compressed responses
+	Types string `json:"types,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if (c1.Enabled == nil) != (c2.Enabled == nil) {
+		return false
+	}
+	if c1.Enabled != nil && *c1.Enabled != *c2.Enabled {
+		return false
+	}
+
+	return c1.Types == c2.Types
+}
+
+type compression struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new compression annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return compression{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to enable or disable the compression of the responses
+func (a compression) Parse(ing *networking.Ingress) (interface{}, error) {
+	config := &Config{}
+
+	enabled, err := parser.GetBoolAnnotation("enable-compression", ing)
+	if err == nil {
+		config.Enabled = &enabled
+	} else if !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+
+	val, err := parser.GetStringAnnotation("compression-types", ing)
+	if err != nil && !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+	types := strings.FieldsFunc(val, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	for _, t := range types {
+		if !mimeTypeRegex.MatchString(t) {
+			return nil, errors.NewInvalidAnnotationContent("compression-types", val)
+		}
+	}
+	config.Types = strings.Join(types, " ")
+
+	if config.Enabled == nil && config.Types == "" {
+		return nil, errors.ErrMissingAnnotations
+	}
+	return config, nil
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package compression
+
+import (
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+)
+
+func TestParse(t *testing.T) {
+	enable := parser.GetAnnotationWithPrefix("enable-compression")
+	types := parser.GetAnnotationWithPrefix("compression-types")
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo-bar",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+
+	enabled := true
+	disabled := false
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    *Config
+		missing     bool
+		invalid     bool
+	}{
+		{"no annotations", nil, nil, true, false},
+		{"enabled", map[string]string{enable: "true"}, &Config{Enabled: &enabled}, false, false},
+		{"disabled", map[string]string{enable: "false"}, &Config{Enabled: &disabled}, false, false},
+		{"invalid enabled", map[string]string{enable: "foo"}, nil, false, true},
+		{"types", map[string]string{types: "application/javascript, text/css  image/svg+xml"},
+			&Config{Types: "application/javascript text/css image/svg+xml"}, false, false},
+		{"enabled with types", map[string]string{enable: "true", types: "*"}, &Config{Enabled: &enabled, Types: "*"}, false, false},
+		{"invalid types", map[string]string{types: "text/css;gzip on"}, nil, false, true},
+	}
+
+	for _, tc := range testCases {
+		ing.SetAnnotations(tc.annotations)
+		result, err := ap.Parse(ing)
+		if tc.missing != errors.IsMissingAnnotations(err) {
+			t.Errorf("%v: expected a missing annotations error: %v, but returned %v", tc.name, tc.missing, err)
+		}
+		if tc.invalid != errors.IsInvalidContent(err) {
+			t.Errorf("%v: expected an invalid content error: %v, but returned %v", tc.name, tc.invalid, err)
+		}
+		if err != nil {
+			continue
+		}
+
+		config := result.(*Config)
+		if !config.Equal(tc.expected) {
+			t.Errorf("%v: expected %+v but returned %+v", tc.name, tc.expected, config)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package customhttperrors
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+)
+
+type customhttperrors struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new custom http errors annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return customhttperrors{r}
+}
+
+// Parse parses the annotations contained in the ingress rule used to
+// return the status codes of the upstream responses replaced with the
+// error pages of the default backend
+func (e customhttperrors) Parse(ing *networking.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation("custom-http-errors", ing)
+	if err != nil {
+		return nil, err
+	}
+	if val == "" {
+		return nil, errors.ErrMissingAnnotations
+	}
+
+	codes, err := ParseCodes(val)
+	if err != nil {
+		return nil, errors.NewInvalidAnnotationContent("custom-http-errors", val)
+	}
+	return codes, nil
+}
+
+// ParseCodes parses a comma separated list of status codes between 300
+// and 599 and returns them sorted without duplicates
+func ParseCodes(val string) ([]int, error) {
+	seen := map[int]bool{}
+	codes := []int{}
+	for _, str := range strings.Split(val, ",") {
+		code, err := strconv.Atoi(strings.TrimSpace(str))
+		if err != nil {
+			return nil, err
+		}
+		if code < 300 || code > 599 {
+			return nil, errors.Errorf("%v is not a valid status code for an error page", code)
+		}
+		if !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+	sort.Ints(codes)
+
+	return codes, nil
+}
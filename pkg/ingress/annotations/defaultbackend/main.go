@@ -0,0 +1,73 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package defaultbackend
+
+import (
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+)
+
+// configMapPrefix is the prefix of the default backends that reference a ConfigMap
+const configMapPrefix = "configmap/"
+
+// Config describes where the error pages of an Ingress are obtained. They
+// are returned by a service or read from the <code>.html keys of a ConfigMap,
+// both in the namespace of the Ingress.
+type Config struct {
+	Service   string `json:"service,omitempty"`
+	ConfigMap string `json:"configMap,omitempty"`
+}
+
+type backend struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new default backend annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return backend{r}
+}
+
+// Parse parses the annotations contained in the ingress rule used to
+// reference the service, or the ConfigMap with the prefix configmap/,
+// that provides the error pages
+func (b backend) Parse(ing *networking.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation("default-backend", ing)
+	if err != nil {
+		return nil, err
+	}
+	if val == "" {
+		return nil, errors.ErrMissingAnnotations
+	}
+
+	if strings.HasPrefix(val, configMapPrefix) {
+		name := strings.TrimPrefix(val, configMapPrefix)
+		if len(validation.IsDNS1123Subdomain(name)) > 0 {
+			return nil, errors.NewInvalidAnnotationContent("default-backend", val)
+		}
+		return &Config{ConfigMap: name}, nil
+	}
+
+	if len(validation.IsDNS1035Label(val)) > 0 {
+		return nil, errors.NewInvalidAnnotationContent("default-backend", val)
+	}
+	return &Config{Service: val}, nil
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+
+	return c1.Service == c2.Service && c1.ConfigMap == c2.ConfigMap
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package defaultbackend
+
+import (
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+)
+
+func TestParse(t *testing.T) {
+	annotation := parser.GetAnnotationWithPrefix("default-backend")
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo-bar",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    *Config
+		missing     bool
+		invalid     bool
+	}{
+		{"no annotations", nil, nil, true, false},
+		{"empty annotation", map[string]string{annotation: ""}, nil, true, false},
+		{"service", map[string]string{annotation: "error-pages"}, &Config{Service: "error-pages"}, false, false},
+		{"configmap", map[string]string{annotation: "configmap/error-pages.v1"}, &Config{ConfigMap: "error-pages.v1"}, false, false},
+		{"invalid service", map[string]string{annotation: "default/error-pages"}, nil, false, true},
+		{"invalid configmap", map[string]string{annotation: "configmap/Error_Pages"}, nil, false, true},
+		{"configmap without name", map[string]string{annotation: "configmap/"}, nil, false, true},
+	}
+
+	for _, tc := range testCases {
+		ing.SetAnnotations(tc.annotations)
+		result, err := ap.Parse(ing)
+		if tc.missing != errors.IsMissingAnnotations(err) {
+			t.Errorf("%v: expected a missing annotations error: %v, but returned %v", tc.name, tc.missing, err)
+		}
+		if tc.invalid != errors.IsInvalidContent(err) {
+			t.Errorf("%v: expected an invalid content error: %v, but returned %v", tc.name, tc.invalid, err)
+		}
+		if tc.expected == nil {
+			continue
+		}
+		if !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("%v: expected %+v but returned %+v", tc.name, tc.expected, result)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	c1 := &Config{Service: "error-pages"}
+	if !c1.Equal(&Config{Service: "error-pages"}) {
+		t.Errorf("expected equal configurations")
+	}
+	if c1.Equal(&Config{ConfigMap: "error-pages"}) {
+		t.Errorf("expected different configurations")
+	}
+	if c1.Equal(nil) {
+		t.Errorf("expected a configuration different from nil")
+	}
+}
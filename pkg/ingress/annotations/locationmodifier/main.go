@@ -31,9 +31,12 @@ func NewParser(r resolver.Resolver) parser.IngressAnnotation {
 // Parse parses the annotations contained in the ingress
 // rule used to indicate if the upstream servers should use SSL
 func (a at) Parse(ing *networking.Ingress) (interface{}, error) {
-	ca, _ := parser.GetStringAnnotation("location-modifier", ing)
+	ca, err := parser.GetStringAnnotation("location-modifier", ing)
+	if err != nil {
+		return "", err
+	}
 	if ca != "~" && ca != "=" && ca != "~*" {
-		return "", errors.Errorf("Location modifier type %v is not supported", ca)
+		return "", errors.NewInvalidAnnotationContent("location-modifier", ca)
 	}
 	return ca, nil
 }
@@ -0,0 +1,40 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package proxysetheaders
+
+import (
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+)
+
+type proxySetHeaders struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new proxy set headers annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return proxySetHeaders{r}
+}
+
+// Parse parses the annotations contained in the ingress rule used to
+// reference the ConfigMap, in the namespace of the Ingress, that contains
+// the headers sent to the upstreams
+func (a proxySetHeaders) Parse(ing *networking.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation("proxy-set-headers", ing)
+	if err != nil {
+		return nil, err
+	}
+	if val == "" {
+		return nil, errors.ErrMissingAnnotations
+	}
+
+	if len(validation.IsDNS1123Subdomain(val)) > 0 {
+		return nil, errors.NewInvalidAnnotationContent("proxy-set-headers", val)
+	}
+	return val, nil
+}
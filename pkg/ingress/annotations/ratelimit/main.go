@@ -0,0 +1,218 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package ratelimit
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+	ing_net "github.com/stolostron/management-ingress/pkg/net"
+)
+
+const (
+	// allow 5 times the limit of requests before rejecting them
+	defaultBurstMultiplier = 5
+
+	// size in MB of the shared memory of each zone
+	defaultSharedSize = 5
+)
+
+// Config returns the rate limits of an Ingress. The zones are shared by
+// all the locations of the Ingress.
+type Config struct {
+	// Connections limits the number of concurrent connections of each client,
+	// the burst is not used
+	Connections Zone `json:"connections"`
+	// RPS limits the number of requests per second of each client
+	RPS Zone `json:"rps"`
+	// RPM limits the number of requests per minute of each client
+	RPM Zone `json:"rpm"`
+	// ID is an unique identifier of the Ingress used in the names of the zones
+	ID string `json:"id"`
+	// Allowlist contains the addresses and CIDRs of the clients without limits
+	Allowlist []string `json:"allowlist,omitempty"`
+}
+
+// Zone is a shared memory zone that keeps the state of the limits
+type Zone struct {
+	Name       string `json:"name"`
+	Limit      int    `json:"limit"`
+	Burst      int    `json:"burst"`
+	SharedSize int    `json:"sharedSize"`
+}
+
+// Enabled returns true if the zone limits the clients
+func (z Zone) Enabled() bool {
+	return z.Limit > 0
+}
+
+type ratelimit struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new rate limit annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return ratelimit{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to limit the connections and requests of each client
+func (a ratelimit) Parse(ing *networking.Ingress) (interface{}, error) {
+	rps, err := getLimit("limit-rps", ing)
+	if err != nil {
+		return nil, err
+	}
+	rpm, err := getLimit("limit-rpm", ing)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := getLimit("limit-connections", ing)
+	if err != nil {
+		return nil, err
+	}
+
+	multiplier, err := parser.GetIntAnnotation("limit-burst-multiplier", ing)
+	if errors.IsMissingAnnotations(err) {
+		multiplier = defaultBurstMultiplier
+	} else if err != nil || multiplier < 1 {
+		return nil, errors.NewInvalidAnnotationContent("limit-burst-multiplier", multiplier)
+	}
+
+	var allowlist []string
+	val, _ := parser.GetStringAnnotation("limit-allowlist", ing)
+	if val != "" {
+		ipnets, ips, err := ing_net.ParseIPNets(strings.Split(val, ",")...)
+		if err != nil {
+			return nil, errors.NewInvalidAnnotationContent("limit-allowlist", val)
+		}
+		for k := range ipnets {
+			allowlist = append(allowlist, k)
+		}
+		for k := range ips {
+			allowlist = append(allowlist, k)
+		}
+		sort.Strings(allowlist)
+	}
+
+	if rps == 0 && rpm == 0 && conn == 0 {
+		return nil, errors.ErrMissingAnnotations
+	}
+
+	id := zoneID(ing)
+	config := &Config{
+		ID:        id,
+		Allowlist: allowlist,
+	}
+	if conn > 0 {
+		config.Connections = Zone{
+			Name:       fmt.Sprintf("%v_conn", id),
+			Limit:      conn,
+			SharedSize: defaultSharedSize,
+		}
+	}
+	if rps > 0 {
+		config.RPS = Zone{
+			Name:       fmt.Sprintf("%v_rps", id),
+			Limit:      rps,
+			Burst:      rps * multiplier,
+			SharedSize: defaultSharedSize,
+		}
+	}
+	if rpm > 0 {
+		config.RPM = Zone{
+			Name:       fmt.Sprintf("%v_rpm", id),
+			Limit:      rpm,
+			Burst:      rpm * multiplier,
+			SharedSize: defaultSharedSize,
+		}
+	}
+
+	return config, nil
+}
+
+// getLimit returns the value of a limit annotation, or 0 if it is not defined
+func getLimit(name string, ing *networking.Ingress) (int, error) {
+	limit, err := parser.GetIntAnnotation(name, ing)
+	if errors.IsMissingAnnotations(err) {
+		return 0, nil
+	}
+	if err != nil || limit < 0 {
+		return 0, errors.NewInvalidAnnotationContent(name, limit)
+	}
+	return limit, nil
+}
+
+// zoneID returns an identifier of the Ingress that can be used in the names
+// of the zones and variables of NGINX. The hash of the namespace and name
+// keeps the identifiers unique after replacing the invalid characters.
+func zoneID(ing *networking.Ingress) string {
+	h := fnv.New32a()
+	// #nosec
+	h.Write([]byte(fmt.Sprintf("%v/%v", ing.Namespace, ing.Name)))
+	name := strings.NewReplacer("-", "_", ".", "_").Replace(fmt.Sprintf("%v_%v", ing.Namespace, ing.Name))
+	return fmt.Sprintf("%v_%x", name, h.Sum32())
+}
+
+// Equal tests for equality between two Zone types
+func (z1 *Zone) Equal(z2 *Zone) bool {
+	if z1 == z2 {
+		return true
+	}
+	if z1 == nil || z2 == nil {
+		return false
+	}
+	if z1.Name != z2.Name {
+		return false
+	}
+	if z1.Limit != z2.Limit {
+		return false
+	}
+	if z1.Burst != z2.Burst {
+		return false
+	}
+	if z1.SharedSize != z2.SharedSize {
+		return false
+	}
+
+	return true
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if !(&c1.Connections).Equal(&c2.Connections) {
+		return false
+	}
+	if !(&c1.RPS).Equal(&c2.RPS) {
+		return false
+	}
+	if !(&c1.RPM).Equal(&c2.RPM) {
+		return false
+	}
+	if c1.ID != c2.ID {
+		return false
+	}
+	if len(c1.Allowlist) != len(c2.Allowlist) {
+		return false
+	}
+	for i := range c1.Allowlist {
+		if c1.Allowlist[i] != c2.Allowlist[i] {
+			return false
+		}
+	}
+
+	return true
+}
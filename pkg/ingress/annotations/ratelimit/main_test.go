@@ -0,0 +1,93 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package ratelimit
+
+import (
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+)
+
+func TestParse(t *testing.T) {
+	rps := parser.GetAnnotationWithPrefix("limit-rps")
+	rpm := parser.GetAnnotationWithPrefix("limit-rpm")
+	conn := parser.GetAnnotationWithPrefix("limit-connections")
+	multiplier := parser.GetAnnotationWithPrefix("limit-burst-multiplier")
+	allowlist := parser.GetAnnotationWithPrefix("limit-allowlist")
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo-bar",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+	id := zoneID(ing)
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    *Config
+		missing     bool
+		invalid     bool
+	}{
+		{"no annotations", nil, nil, true, false},
+		{"only allowlist", map[string]string{allowlist: "10.0.0.0/8"}, nil, true, false},
+		{"rps", map[string]string{rps: "10"}, &Config{
+			ID:  id,
+			RPS: Zone{Name: id + "_rps", Limit: 10, Burst: 50, SharedSize: defaultSharedSize},
+		}, false, false},
+		{"rpm and connections", map[string]string{rpm: "60", conn: "5", multiplier: "2"}, &Config{
+			ID:          id,
+			RPM:         Zone{Name: id + "_rpm", Limit: 60, Burst: 120, SharedSize: defaultSharedSize},
+			Connections: Zone{Name: id + "_conn", Limit: 5, SharedSize: defaultSharedSize},
+		}, false, false},
+		{"allowlist", map[string]string{rps: "1", allowlist: "192.168.0.1, 10.0.0.0/8"}, &Config{
+			ID:        id,
+			RPS:       Zone{Name: id + "_rps", Limit: 1, Burst: 5, SharedSize: defaultSharedSize},
+			Allowlist: []string{"10.0.0.0/8", "192.168.0.1"},
+		}, false, false},
+		{"invalid rps", map[string]string{rps: "-1"}, nil, false, true},
+		{"rpm not a number", map[string]string{rpm: "many"}, nil, false, true},
+		{"invalid multiplier", map[string]string{rps: "1", multiplier: "0"}, nil, false, true},
+		{"invalid allowlist", map[string]string{rps: "1", allowlist: "10.0.0.0/33"}, nil, false, true},
+	}
+
+	for _, tc := range testCases {
+		ing.SetAnnotations(tc.annotations)
+		result, err := ap.Parse(ing)
+		if tc.missing != errors.IsMissingAnnotations(err) {
+			t.Errorf("%v: expected a missing annotations error: %v, but returned %v", tc.name, tc.missing, err)
+		}
+		if tc.invalid != errors.IsInvalidContent(err) {
+			t.Errorf("%v: expected an invalid content error: %v, but returned %v", tc.name, tc.invalid, err)
+		}
+		if tc.expected == nil {
+			continue
+		}
+		if !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("%v: expected %+v but returned %+v", tc.name, tc.expected, result)
+		}
+	}
+}
+
+func TestZoneID(t *testing.T) {
+	a := &networking.Ingress{ObjectMeta: meta_v1.ObjectMeta{Namespace: "a-b", Name: "c"}}
+	b := &networking.Ingress{ObjectMeta: meta_v1.ObjectMeta{Namespace: "a", Name: "b-c"}}
+	if zoneID(a) == zoneID(b) {
+		t.Errorf("expected different identifiers for %v/%v and %v/%v", a.Namespace, a.Name, b.Namespace, b.Name)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package redirect
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+)
+
+var (
+	permanentCodes = []int{http.StatusMovedPermanently, http.StatusPermanentRedirect}
+	temporalCodes  = []int{http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect}
+)
+
+// Config describes the redirection of the requests of a location
+type Config struct {
+	// URL where the requests are redirected
+	URL string `json:"url,omitempty"`
+	// Code is the status code of the redirection
+	Code int `json:"code,omitempty"`
+	// FromToWWW indicates the requests of the host with or without
+	// the prefix www. are redirected to the host of the Ingress
+	FromToWWW bool `json:"fromToWWW,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (r1 *Config) Equal(r2 *Config) bool {
+	if r1 == r2 {
+		return true
+	}
+	if r1 == nil || r2 == nil {
+		return false
+	}
+
+	return r1.URL == r2.URL && r1.Code == r2.Code && r1.FromToWWW == r2.FromToWWW
+}
+
+type redirect struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new redirect annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return redirect{r}
+}
+
+// Parse parses the annotations contained in the ingress rule used to
+// redirect the requests to an URL. The temporal redirection takes
+// precedence over the permanent one.
+func (a redirect) Parse(ing *networking.Ingress) (interface{}, error) {
+	fromToWWW, _ := parser.GetBoolAnnotation("from-to-www-redirect", ing)
+
+	tr, _ := parser.GetStringAnnotation("temporal-redirect", ing)
+	if tr != "" {
+		code, err := parseCode("temporal-redirect-code", http.StatusFound, temporalCodes, ing)
+		if err != nil {
+			return nil, err
+		}
+		if !isValidURL(tr) {
+			return nil, errors.NewInvalidAnnotationContent("temporal-redirect", tr)
+		}
+		return &Config{URL: tr, Code: code, FromToWWW: fromToWWW}, nil
+	}
+
+	pr, _ := parser.GetStringAnnotation("permanent-redirect", ing)
+	if pr != "" {
+		code, err := parseCode("permanent-redirect-code", http.StatusMovedPermanently, permanentCodes, ing)
+		if err != nil {
+			return nil, err
+		}
+		if !isValidURL(pr) {
+			return nil, errors.NewInvalidAnnotationContent("permanent-redirect", pr)
+		}
+		return &Config{URL: pr, Code: code, FromToWWW: fromToWWW}, nil
+	}
+
+	if fromToWWW {
+		return &Config{FromToWWW: fromToWWW}, nil
+	}
+
+	return nil, errors.ErrMissingAnnotations
+}
+
+// parseCode returns the status code of a redirection, or
+// the default when the annotation is not defined
+func parseCode(name string, def int, valid []int, ing *networking.Ingress) (int, error) {
+	code, err := parser.GetIntAnnotation(name, ing)
+	if err != nil {
+		if errors.IsMissingAnnotations(err) {
+			return def, nil
+		}
+		return 0, err
+	}
+
+	for _, v := range valid {
+		if code == v {
+			return code, nil
+		}
+	}
+	return 0, errors.NewInvalidAnnotationContent(name, code)
+}
+
+// isValidURL checks if the URL of a redirection is absolute and
+// can be included in the NGINX configuration
+func isValidURL(val string) bool {
+	if strings.ContainsAny(val, " \t\r\n\"';{}") {
+		return false
+	}
+
+	u, err := url.Parse(val)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
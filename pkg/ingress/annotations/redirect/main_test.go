@@ -0,0 +1,76 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package redirect
+
+import (
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+)
+
+func TestParse(t *testing.T) {
+	permanent := parser.GetAnnotationWithPrefix("permanent-redirect")
+	permanentCode := parser.GetAnnotationWithPrefix("permanent-redirect-code")
+	temporal := parser.GetAnnotationWithPrefix("temporal-redirect")
+	temporalCode := parser.GetAnnotationWithPrefix("temporal-redirect-code")
+	fromToWWW := parser.GetAnnotationWithPrefix("from-to-www-redirect")
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo-bar",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    *Config
+		missing     bool
+		invalid     bool
+	}{
+		{"no annotations", nil, nil, true, false},
+		{"from-to-www disabled", map[string]string{fromToWWW: "false"}, nil, true, false},
+		{"permanent", map[string]string{permanent: "https://example.com/new"}, &Config{URL: "https://example.com/new", Code: 301}, false, false},
+		{"permanent with code", map[string]string{permanent: "https://example.com", permanentCode: "308"}, &Config{URL: "https://example.com", Code: 308}, false, false},
+		{"temporal", map[string]string{temporal: "http://example.com/$request_uri"}, &Config{URL: "http://example.com/$request_uri", Code: 302}, false, false},
+		{"temporal with code", map[string]string{temporal: "https://example.com", temporalCode: "307"}, &Config{URL: "https://example.com", Code: 307}, false, false},
+		{"temporal over permanent", map[string]string{temporal: "https://example.com/tmp", permanent: "https://example.com"}, &Config{URL: "https://example.com/tmp", Code: 302}, false, false},
+		{"from-to-www", map[string]string{fromToWWW: "true"}, &Config{FromToWWW: true}, false, false},
+		{"invalid permanent code", map[string]string{permanent: "https://example.com", permanentCode: "302"}, nil, false, true},
+		{"invalid temporal code", map[string]string{temporal: "https://example.com", temporalCode: "foo"}, nil, false, true},
+		{"relative url", map[string]string{permanent: "/new"}, nil, false, true},
+		{"url with semicolon", map[string]string{permanent: "https://example.com/;return 200"}, nil, false, true},
+	}
+
+	for _, tc := range testCases {
+		ing.SetAnnotations(tc.annotations)
+		result, err := ap.Parse(ing)
+		if tc.missing != errors.IsMissingAnnotations(err) {
+			t.Errorf("%v: expected a missing annotations error: %v, but returned %v", tc.name, tc.missing, err)
+		}
+		if tc.invalid != errors.IsInvalidContent(err) {
+			t.Errorf("%v: expected an invalid content error: %v, but returned %v", tc.name, tc.invalid, err)
+		}
+		if tc.expected == nil {
+			continue
+		}
+		if !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("%v: expected %+v but returned %+v", tc.name, tc.expected, result)
+		}
+	}
+}
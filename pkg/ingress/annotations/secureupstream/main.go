@@ -13,11 +13,14 @@ package secureupstream
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/pkg/errors"
 	networking "k8s.io/api/networking/v1"
 
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/backendprotocol"
 	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	ing_errors "github.com/stolostron/management-ingress/pkg/ingress/errors"
 	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
 )
 
@@ -41,6 +44,10 @@ func NewParser(r resolver.Resolver) parser.IngressAnnotation {
 // rule used to indicate if the upstream servers should use SSL
 func (a su) Parse(ing *networking.Ingress) (interface{}, error) {
 	s, _ := parser.GetBoolAnnotation("secure-backends", ing)
+	// the backend-protocol annotation takes precedence over secure-backends
+	if proto, _ := parser.GetStringAnnotation("backend-protocol", ing); proto != "" {
+		s = backendprotocol.IsSecure(strings.ToUpper(strings.TrimSpace(proto)))
+	}
 	ca, _ := parser.GetStringAnnotation("secure-verify-ca-secret", ing)
 	clientca, _ := parser.GetStringAnnotation("secure-client-ca-secret", ing)
 
@@ -53,12 +60,14 @@ func (a su) Parse(ing *networking.Ingress) (interface{}, error) {
 		ClientCACert: _clientCACert,
 	}
 	if !s && ca != "" {
-		return secure,
-			errors.Errorf("trying to use CA from secret %v/%v on a non secure backend", ing.Namespace, ca)
+		return secure, ing_errors.InvalidContent{
+			Name: fmt.Sprintf("trying to use CA from secret %v/%v on a non secure backend", ing.Namespace, ca),
+		}
 	}
 	if !s && clientca != "" {
-		return secure,
-			errors.Errorf("trying to use Client CA from secret %v/%v on a non secure backend", ing.Namespace, clientca)
+		return secure, ing_errors.InvalidContent{
+			Name: fmt.Sprintf("trying to use Client CA from secret %v/%v on a non secure backend", ing.Namespace, clientca),
+		}
 	}
 
 	if ca != "" {
@@ -150,3 +150,32 @@ func TestClientSecretOnNonSecure(t *testing.T) {
 		t.Error("Expected Client CA secret on non secure backend error on ingress")
 	}
 }
+
+func TestBackendProtocol(t *testing.T) {
+	testCases := []struct {
+		protocol string
+		secure   bool
+	}{
+		{"HTTPS", true},
+		{"grpcs", true},
+		{"GRPC", false},
+		{"H2C", false},
+	}
+
+	for _, tc := range testCases {
+		ing := buildIngress()
+		data := map[string]string{}
+		data[parser.GetAnnotationWithPrefix("secure-backends")] = "true"
+		data[parser.GetAnnotationWithPrefix("backend-protocol")] = tc.protocol
+		ing.SetAnnotations(data)
+
+		result, err := NewParser(mockCfg{}).Parse(ing)
+		if err != nil {
+			t.Errorf("%v: unexpected error on ingress: %v", tc.protocol, err)
+			continue
+		}
+		if secure := result.(*Config).Secure; secure != tc.secure {
+			t.Errorf("%v: expected secure %v but returned %v", tc.protocol, tc.secure, secure)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package securityheaders
+
+import (
+	"regexp"
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+)
+
+var (
+	// the values are rendered in quoted add_header directives
+	headerValueRegex = regexp.MustCompile(`^[^"\\\r\n]*$`)
+	maxAgeRegex      = regexp.MustCompile(`^\d+$`)
+)
+
+// Config describes the security headers of the responses overridden by an
+// Ingress. The fields are nil when the Ingress does not define the
+// annotations, and the values of the configuration are used.
+type Config struct {
+	// XFrameOptions is DENY, SAMEORIGIN or empty to remove the header
+	XFrameOptions *string `json:"xFrameOptions,omitempty"`
+	// ContentSecurityPolicy is the policy of the responses, or empty to remove the header
+	ContentSecurityPolicy *string `json:"contentSecurityPolicy,omitempty"`
+	// HSTS enables or disables the header Strict-Transport-Security
+	HSTS *bool `json:"hsts,omitempty"`
+	// HSTSMaxAge is the max-age in seconds of the header Strict-Transport-Security
+	HSTSMaxAge *string `json:"hstsMaxAge,omitempty"`
+	// HSTSIncludeSubdomains adds includeSubDomains to the header Strict-Transport-Security
+	HSTSIncludeSubdomains *bool `json:"hstsIncludeSubdomains,omitempty"`
+	// HSTSPreload adds preload to the header Strict-Transport-Security
+	HSTSPreload *bool `json:"hstsPreload,omitempty"`
+}
+
+type securityHeaders struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new security headers annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return securityHeaders{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to override the security headers of the responses
+func (a securityHeaders) Parse(ing *networking.Ingress) (interface{}, error) {
+	config := &Config{}
+
+	frameOptions, err := parser.GetStringAnnotation("x-frame-options", ing)
+	if err == nil {
+		frameOptions = strings.ToUpper(strings.TrimSpace(frameOptions))
+		if frameOptions != "" && frameOptions != "DENY" && frameOptions != "SAMEORIGIN" {
+			return nil, errors.NewInvalidAnnotationContent("x-frame-options", frameOptions)
+		}
+		config.XFrameOptions = &frameOptions
+	} else if !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+
+	csp, err := parser.GetStringAnnotation("content-security-policy", ing)
+	if err == nil {
+		csp = strings.TrimSpace(csp)
+		if !headerValueRegex.MatchString(csp) {
+			return nil, errors.NewInvalidAnnotationContent("content-security-policy", csp)
+		}
+		config.ContentSecurityPolicy = &csp
+	} else if !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+
+	hsts, err := parser.GetBoolAnnotation("hsts", ing)
+	if err == nil {
+		config.HSTS = &hsts
+	} else if !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+
+	maxAge, err := parser.GetStringAnnotation("hsts-max-age", ing)
+	if err == nil {
+		if !maxAgeRegex.MatchString(maxAge) {
+			return nil, errors.NewInvalidAnnotationContent("hsts-max-age", maxAge)
+		}
+		config.HSTSMaxAge = &maxAge
+	} else if !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+
+	includeSubdomains, err := parser.GetBoolAnnotation("hsts-include-subdomains", ing)
+	if err == nil {
+		config.HSTSIncludeSubdomains = &includeSubdomains
+	} else if !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+
+	preload, err := parser.GetBoolAnnotation("hsts-preload", ing)
+	if err == nil {
+		config.HSTSPreload = &preload
+	} else if !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+
+	if *config == (Config{}) {
+		return nil, errors.ErrMissingAnnotations
+	}
+	return config, nil
+}
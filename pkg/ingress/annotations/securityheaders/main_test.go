@@ -0,0 +1,81 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package securityheaders
+
+import (
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+)
+
+func TestParse(t *testing.T) {
+	frameOptions := parser.GetAnnotationWithPrefix("x-frame-options")
+	csp := parser.GetAnnotationWithPrefix("content-security-policy")
+	hsts := parser.GetAnnotationWithPrefix("hsts")
+	maxAge := parser.GetAnnotationWithPrefix("hsts-max-age")
+	preload := parser.GetAnnotationWithPrefix("hsts-preload")
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo-bar",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+
+	str := func(s string) *string { return &s }
+	boolean := func(b bool) *bool { return &b }
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    *Config
+		missing     bool
+		invalid     bool
+	}{
+		{"no annotations", nil, nil, true, false},
+		{"frame options", map[string]string{frameOptions: "deny"}, &Config{XFrameOptions: str("DENY")}, false, false},
+		{"without frame options", map[string]string{frameOptions: ""}, &Config{XFrameOptions: str("")}, false, false},
+		{"invalid frame options", map[string]string{frameOptions: "ALLOW-FROM https://example.com"}, nil, false, true},
+		{"content security policy", map[string]string{csp: "frame-ancestors 'self' https://console.example.com"},
+			&Config{ContentSecurityPolicy: str("frame-ancestors 'self' https://console.example.com")}, false, false},
+		{"invalid content security policy", map[string]string{csp: `default-src "self"`}, nil, false, true},
+		{"hsts", map[string]string{hsts: "true", maxAge: "31536000", preload: "true"},
+			&Config{HSTS: boolean(true), HSTSMaxAge: str("31536000"), HSTSPreload: boolean(true)}, false, false},
+		{"hsts disabled", map[string]string{hsts: "false"}, &Config{HSTS: boolean(false)}, false, false},
+		{"invalid hsts", map[string]string{hsts: "foo"}, nil, false, true},
+		{"invalid max age", map[string]string{maxAge: "1y"}, nil, false, true},
+	}
+
+	for _, tc := range testCases {
+		ing.SetAnnotations(tc.annotations)
+		result, err := ap.Parse(ing)
+		if tc.missing != errors.IsMissingAnnotations(err) {
+			t.Errorf("%v: expected a missing annotations error: %v, but returned %v", tc.name, tc.missing, err)
+		}
+		if tc.invalid != errors.IsInvalidContent(err) {
+			t.Errorf("%v: expected an invalid content error: %v, but returned %v", tc.name, tc.invalid, err)
+		}
+		if err != nil {
+			continue
+		}
+
+		config := result.(*Config)
+		if !reflect.DeepEqual(config, tc.expected) {
+			t.Errorf("%v: expected %+v but returned %+v", tc.name, tc.expected, config)
+		}
+	}
+}
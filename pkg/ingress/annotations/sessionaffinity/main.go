@@ -0,0 +1,149 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package sessionaffinity
+
+import (
+	"regexp"
+
+	networking "k8s.io/api/networking/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+)
+
+const (
+	// CookieAffinity routes the requests with the same cookie to the same endpoint
+	CookieAffinity = "cookie"
+
+	defaultCookieName = "INGRESSCOOKIE"
+	defaultCookieHash = "md5"
+)
+
+var (
+	cookieNameRegex = regexp.MustCompile(`^[A-Za-z0-9!#$%&'*+.^_|~-]+$`)
+	cookieHashRegex = regexp.MustCompile(`^(md5|sha1)$`)
+	cookiePathRegex = regexp.MustCompile(`^/[^"\;{}\s]*$`)
+	sameSiteRegex   = regexp.MustCompile(`^(None|Lax|Strict)$`)
+)
+
+// Config describes the session affinity of the backends of an Ingress
+type Config struct {
+	Type   string       `json:"type"`
+	Cookie CookieConfig `json:"cookie"`
+}
+
+// CookieConfig describes the cookie that identifies the endpoint of a session.
+// The value of the cookie is the hash of the address of the endpoint.
+type CookieConfig struct {
+	Name string `json:"name"`
+	Hash string `json:"hash"`
+	// Expires and MaxAge are the lifetime of the cookie in seconds,
+	// the cookie expires with the browser session when both are 0
+	Expires int `json:"expires,omitempty"`
+	MaxAge  int `json:"maxAge,omitempty"`
+	// Path of the cookie, the path of each location is used when it is empty
+	Path     string `json:"path,omitempty"`
+	SameSite string `json:"sameSite,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+type affinity struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new session affinity annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return affinity{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to route the requests of a session to the same endpoint
+func (a affinity) Parse(ing *networking.Ingress) (interface{}, error) {
+	at, err := parser.GetStringAnnotation("affinity", ing)
+	if err != nil {
+		return nil, err
+	}
+	if at == "" {
+		return nil, errors.ErrMissingAnnotations
+	}
+	if at != CookieAffinity {
+		return nil, errors.NewInvalidAnnotationContent("affinity", at)
+	}
+
+	cookie := CookieConfig{
+		Name: defaultCookieName,
+		Hash: defaultCookieHash,
+	}
+
+	if name, _ := parser.GetStringAnnotation("session-cookie-name", ing); name != "" {
+		if !cookieNameRegex.MatchString(name) {
+			return nil, errors.NewInvalidAnnotationContent("session-cookie-name", name)
+		}
+		cookie.Name = name
+	}
+
+	if hash, _ := parser.GetStringAnnotation("session-cookie-hash", ing); hash != "" {
+		if !cookieHashRegex.MatchString(hash) {
+			return nil, errors.NewInvalidAnnotationContent("session-cookie-hash", hash)
+		}
+		cookie.Hash = hash
+	}
+
+	if cookie.Expires, err = getSeconds("session-cookie-expires", ing); err != nil {
+		return nil, err
+	}
+	if cookie.MaxAge, err = getSeconds("session-cookie-max-age", ing); err != nil {
+		return nil, err
+	}
+
+	if path, _ := parser.GetStringAnnotation("session-cookie-path", ing); path != "" {
+		if !cookiePathRegex.MatchString(path) {
+			return nil, errors.NewInvalidAnnotationContent("session-cookie-path", path)
+		}
+		cookie.Path = path
+	}
+
+	if sameSite, _ := parser.GetStringAnnotation("session-cookie-samesite", ing); sameSite != "" {
+		if !sameSiteRegex.MatchString(sameSite) {
+			return nil, errors.NewInvalidAnnotationContent("session-cookie-samesite", sameSite)
+		}
+		cookie.SameSite = sameSite
+	}
+
+	cookie.Secure, _ = parser.GetBoolAnnotation("session-cookie-secure", ing)
+
+	return &Config{
+		Type:   at,
+		Cookie: cookie,
+	}, nil
+}
+
+// getSeconds returns the value of an annotation with a number of seconds,
+// or 0 if it is not defined
+func getSeconds(name string, ing *networking.Ingress) (int, error) {
+	seconds, err := parser.GetIntAnnotation(name, ing)
+	if errors.IsMissingAnnotations(err) {
+		return 0, nil
+	}
+	if err != nil || seconds < 0 {
+		return 0, errors.NewInvalidAnnotationContent(name, seconds)
+	}
+	return seconds, nil
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Type != c2.Type {
+		return false
+	}
+
+	return c1.Cookie == c2.Cookie
+}
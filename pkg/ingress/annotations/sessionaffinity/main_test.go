@@ -0,0 +1,101 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package sessionaffinity
+
+import (
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+)
+
+func TestParse(t *testing.T) {
+	affinity := parser.GetAnnotationWithPrefix("affinity")
+	name := parser.GetAnnotationWithPrefix("session-cookie-name")
+	hash := parser.GetAnnotationWithPrefix("session-cookie-hash")
+	expires := parser.GetAnnotationWithPrefix("session-cookie-expires")
+	maxAge := parser.GetAnnotationWithPrefix("session-cookie-max-age")
+	path := parser.GetAnnotationWithPrefix("session-cookie-path")
+	sameSite := parser.GetAnnotationWithPrefix("session-cookie-samesite")
+	secure := parser.GetAnnotationWithPrefix("session-cookie-secure")
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo-bar",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    *Config
+		missing     bool
+		invalid     bool
+	}{
+		{"no annotations", nil, nil, true, false},
+		{"only cookie attributes", map[string]string{name: "route"}, nil, true, false},
+		{"defaults", map[string]string{affinity: "cookie"}, &Config{
+			Type:   CookieAffinity,
+			Cookie: CookieConfig{Name: defaultCookieName, Hash: defaultCookieHash},
+		}, false, false},
+		{"all annotations", map[string]string{
+			affinity: "cookie",
+			name:     "route",
+			hash:     "sha1",
+			expires:  "3600",
+			maxAge:   "7200",
+			path:     "/console",
+			sameSite: "Strict",
+			secure:   "true",
+		}, &Config{
+			Type: CookieAffinity,
+			Cookie: CookieConfig{
+				Name:     "route",
+				Hash:     "sha1",
+				Expires:  3600,
+				MaxAge:   7200,
+				Path:     "/console",
+				SameSite: "Strict",
+				Secure:   true,
+			},
+		}, false, false},
+		{"invalid type", map[string]string{affinity: "ip"}, nil, false, true},
+		{"invalid name", map[string]string{affinity: "cookie", name: "a=b"}, nil, false, true},
+		{"invalid hash", map[string]string{affinity: "cookie", hash: "sha256"}, nil, false, true},
+		{"invalid expires", map[string]string{affinity: "cookie", expires: "-1"}, nil, false, true},
+		{"invalid max age", map[string]string{affinity: "cookie", maxAge: "forever"}, nil, false, true},
+		{"invalid path", map[string]string{affinity: "cookie", path: "console"}, nil, false, true},
+		{"invalid samesite", map[string]string{affinity: "cookie", sameSite: "strict"}, nil, false, true},
+	}
+
+	for _, tc := range testCases {
+		ing.SetAnnotations(tc.annotations)
+		result, err := ap.Parse(ing)
+		if tc.missing != errors.IsMissingAnnotations(err) {
+			t.Errorf("%v: expected a missing annotations error: %v, but returned %v", tc.name, tc.missing, err)
+		}
+		if tc.invalid != errors.IsInvalidContent(err) {
+			t.Errorf("%v: expected an invalid content error: %v, but returned %v", tc.name, tc.invalid, err)
+		}
+		if tc.expected == nil {
+			continue
+		}
+		if !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("%v: expected %+v but returned %+v", tc.name, tc.expected, result)
+		}
+	}
+}
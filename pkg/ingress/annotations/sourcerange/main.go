@@ -0,0 +1,116 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package sourcerange
+
+import (
+	"sort"
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+	ing_net "github.com/stolostron/management-ingress/pkg/net"
+)
+
+// Config returns the source ranges of the clients allowed and denied
+// to access the locations of an Ingress. When the allowlist is not empty
+// only its clients are allowed. The most specific CIDR that contains the
+// client decides, and the denylist wins only when both contain the same CIDR.
+type Config struct {
+	Allowlist []string `json:"allowlist,omitempty"`
+	Denylist  []string `json:"denylist,omitempty"`
+}
+
+type sourcerange struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new source range annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return sourcerange{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to limit the access to clients of specific source ranges
+func (a sourcerange) Parse(ing *networking.Ingress) (interface{}, error) {
+	allowlist, err := getSourceRange("allowlist-source-range", ing)
+	if err != nil {
+		return nil, err
+	}
+
+	denylist, err := getSourceRange("denylist-source-range", ing)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(allowlist) == 0 && len(denylist) == 0 {
+		return nil, errors.ErrMissingAnnotations
+	}
+
+	return &Config{
+		Allowlist: allowlist,
+		Denylist:  denylist,
+	}, nil
+}
+
+// getSourceRange returns the sorted list of CIDRs and addresses of an annotation
+func getSourceRange(name string, ing *networking.Ingress) ([]string, error) {
+	val, err := parser.GetStringAnnotation(name, ing)
+	if err != nil || val == "" {
+		return nil, nil
+	}
+
+	ranges, err := ParseSourceRange(val)
+	if err != nil {
+		return nil, errors.NewInvalidAnnotationContent(name, val)
+	}
+	return ranges, nil
+}
+
+// ParseSourceRange parses a comma separated list of CIDRs and addresses
+// and returns them sorted in their canonical format
+func ParseSourceRange(val string) ([]string, error) {
+	ipnets, ips, err := ing_net.ParseIPNets(strings.Split(val, ",")...)
+	if err != nil {
+		return nil, err
+	}
+
+	var ranges []string
+	for k := range ipnets {
+		ranges = append(ranges, k)
+	}
+	for k := range ips {
+		ranges = append(ranges, k)
+	}
+	sort.Strings(ranges)
+
+	return ranges, nil
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+
+	return equalRanges(c1.Allowlist, c2.Allowlist) && equalRanges(c1.Denylist, c2.Denylist)
+}
+
+func equalRanges(r1, r2 []string) bool {
+	if len(r1) != len(r2) {
+		return false
+	}
+	for i := range r1 {
+		if r1[i] != r2[i] {
+			return false
+		}
+	}
+
+	return true
+}
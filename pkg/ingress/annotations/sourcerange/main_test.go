@@ -0,0 +1,75 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package sourcerange
+
+import (
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+)
+
+func TestParse(t *testing.T) {
+	allowlist := parser.GetAnnotationWithPrefix("allowlist-source-range")
+	denylist := parser.GetAnnotationWithPrefix("denylist-source-range")
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo-bar",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    *Config
+		missing     bool
+		invalid     bool
+	}{
+		{"no annotations", nil, nil, true, false},
+		{"empty annotations", map[string]string{allowlist: "", denylist: ""}, nil, true, false},
+		{"allowlist", map[string]string{allowlist: "192.168.0.1, 10.0.0.0/8"}, &Config{
+			Allowlist: []string{"10.0.0.0/8", "192.168.0.1"},
+		}, false, false},
+		{"denylist", map[string]string{denylist: "10.1.0.0/16"}, &Config{
+			Denylist: []string{"10.1.0.0/16"},
+		}, false, false},
+		{"both", map[string]string{allowlist: "10.0.0.0/8", denylist: "10.1.0.0/16,2001:db8::/32"}, &Config{
+			Allowlist: []string{"10.0.0.0/8"},
+			Denylist:  []string{"10.1.0.0/16", "2001:db8::/32"},
+		}, false, false},
+		{"invalid allowlist", map[string]string{allowlist: "10.0.0.0/33"}, nil, false, true},
+		{"invalid denylist", map[string]string{allowlist: "10.0.0.0/8", denylist: "foo"}, nil, false, true},
+	}
+
+	for _, tc := range testCases {
+		ing.SetAnnotations(tc.annotations)
+		result, err := ap.Parse(ing)
+		if tc.missing != errors.IsMissingAnnotations(err) {
+			t.Errorf("%v: expected a missing annotations error: %v, but returned %v", tc.name, tc.missing, err)
+		}
+		if tc.invalid != errors.IsInvalidContent(err) {
+			t.Errorf("%v: expected an invalid content error: %v, but returned %v", tc.name, tc.invalid, err)
+		}
+		if tc.expected == nil {
+			continue
+		}
+		if !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("%v: expected %+v but returned %+v", tc.name, tc.expected, result)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package sslredirect
+
+import (
+	networking "k8s.io/api/networking/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+)
+
+// Config describes the redirection of the HTTP requests to HTTPS. The
+// fields are nil when the Ingress does not define the annotations, and
+// the values of the configuration are used.
+type Config struct {
+	// SSLRedirect redirects the requests of the servers with a certificate
+	SSLRedirect *bool `json:"sslRedirect,omitempty"`
+	// ForceSSLRedirect redirects the requests even when
+	// the server does not have a certificate
+	ForceSSLRedirect *bool `json:"forceSSLRedirect,omitempty"`
+}
+
+type sslRedirect struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new SSL redirect annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return sslRedirect{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to redirect the HTTP requests to HTTPS
+func (a sslRedirect) Parse(ing *networking.Ingress) (interface{}, error) {
+	config := &Config{}
+
+	ssl, err := parser.GetBoolAnnotation("ssl-redirect", ing)
+	if err == nil {
+		config.SSLRedirect = &ssl
+	} else if !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+
+	force, err := parser.GetBoolAnnotation("force-ssl-redirect", ing)
+	if err == nil {
+		config.ForceSSLRedirect = &force
+	} else if !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+
+	if config.SSLRedirect == nil && config.ForceSSLRedirect == nil {
+		return nil, errors.ErrMissingAnnotations
+	}
+	return config, nil
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package sslredirect
+
+import (
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+)
+
+func TestParse(t *testing.T) {
+	ssl := parser.GetAnnotationWithPrefix("ssl-redirect")
+	force := parser.GetAnnotationWithPrefix("force-ssl-redirect")
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo-bar",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		ssl         string
+		force       string
+		missing     bool
+		invalid     bool
+	}{
+		{"no annotations", nil, "", "", true, false},
+		{"ssl redirect", map[string]string{ssl: "true"}, "true", "nil", false, false},
+		{"ssl redirect disabled", map[string]string{ssl: "false"}, "false", "nil", false, false},
+		{"force ssl redirect", map[string]string{force: "true"}, "nil", "true", false, false},
+		{"both", map[string]string{ssl: "false", force: "true"}, "false", "true", false, false},
+		{"invalid", map[string]string{ssl: "foo"}, "", "", false, true},
+	}
+
+	for _, tc := range testCases {
+		ing.SetAnnotations(tc.annotations)
+		result, err := ap.Parse(ing)
+		if tc.missing != errors.IsMissingAnnotations(err) {
+			t.Errorf("%v: expected a missing annotations error: %v, but returned %v", tc.name, tc.missing, err)
+		}
+		if tc.invalid != errors.IsInvalidContent(err) {
+			t.Errorf("%v: expected an invalid content error: %v, but returned %v", tc.name, tc.invalid, err)
+		}
+		if err != nil {
+			continue
+		}
+
+		config := result.(*Config)
+		if value := formatBool(config.SSLRedirect); value != tc.ssl {
+			t.Errorf("%v: expected ssl-redirect %v but returned %v", tc.name, tc.ssl, value)
+		}
+		if value := formatBool(config.ForceSSLRedirect); value != tc.force {
+			t.Errorf("%v: expected force-ssl-redirect %v but returned %v", tc.name, tc.force, value)
+		}
+	}
+}
+
+func formatBool(b *bool) string {
+	if b == nil {
+		return "nil"
+	}
+	if *b {
+		return "true"
+	}
+	return "false"
+}
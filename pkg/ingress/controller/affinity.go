@@ -0,0 +1,36 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"github.com/golang/glog"
+
+	networking "k8s.io/api/networking/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/sessionaffinity"
+)
+
+// setSessionAffinity configures the session affinity of an Ingress in one of
+// its backends. A backend can be used by more than one Ingress, the affinity
+// of the oldest Ingress that defines it is used and the Ingresses with a
+// different affinity are reported. The owners map contains the Ingress that
+// defined the affinity of each backend.
+func (n *NGINXController) setSessionAffinity(ups *ingress.Backend, ing *networking.Ingress, affinity sessionaffinity.Config, owners map[string]*networking.Ingress) {
+	if affinity.Type == "" {
+		return
+	}
+
+	owner, ok := owners[ups.Name]
+	if !ok {
+		ups.SessionAffinity = affinity
+		owners[ups.Name] = ing
+		return
+	}
+
+	if owner != ing && !(&ups.SessionAffinity).Equal(&affinity) {
+		glog.Warningf("ingress %v defines a session affinity for backend %v different from the one of ingress %v", ingressKey(ing), ups.Name, ingressKey(owner))
+		n.recordWarning(ing, reasonConflict, "backend %v already uses a different session affinity defined by ingress %v, the affinity annotations are ignored", ups.Name, ingressKey(owner))
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"testing"
+
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/stolostron/management-ingress/pkg/ingress"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/class"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+)
+
+func TestSessionAffinityConflicts(t *testing.T) {
+	ic := class.IngressClass
+	class.IngressClass = class.DefaultClass
+	defer func() {
+		class.IngressClass = ic
+	}()
+
+	affinity := parser.GetAnnotationWithPrefix("affinity")
+	cookieName := parser.GetAnnotationWithPrefix("session-cookie-name")
+
+	testCases := []struct {
+		name   string
+		second map[string]string
+		event  bool
+	}{
+		{"without affinity", nil, false},
+		{"same affinity", map[string]string{affinity: "cookie", cookieName: "route"}, false},
+		{"different affinity", map[string]string{affinity: "cookie", cookieName: "session"}, true},
+	}
+
+	for _, tc := range testCases {
+		n := buildControllerForChecker(t, "/bin/true")
+		recorder := record.NewFakeRecorder(10)
+		n.recorder = recorder
+		addServiceForChecker(t, n, "foo")
+
+		first := buildIngressForChecker(class.DefaultClass, map[string]string{affinity: "cookie", cookieName: "route"})
+		second := buildIngressForChecker(class.DefaultClass, tc.second)
+		second.Name = "bar"
+		second.Spec.Rules[0].HTTP.Paths[0].Path = "/bar"
+		n.extractAnnotations(first)
+		n.extractAnnotations(second)
+
+		upstreams := n.createUpstreams([]*networking.Ingress{first, second}, &ingress.Backend{})
+		ups := upstreams["default-foo-80"]
+		if ups == nil {
+			t.Fatalf("%v: expected the upstream default-foo-80", tc.name)
+		}
+		if ups.SessionAffinity.Type != "cookie" || ups.SessionAffinity.Cookie.Name != "route" {
+			t.Errorf("%v: expected the affinity of the first ingress but got %+v", tc.name, ups.SessionAffinity)
+		}
+
+		if tc.event != (len(recorder.Events) > 0) {
+			t.Errorf("%v: expected an event %v but got %v", tc.name, tc.event, len(recorder.Events))
+		}
+	}
+}
@@ -127,6 +127,17 @@ func (ic *NGINXController) getPemCertificate(secretName string) (*ingress.SSLCer
 	return s, nil
 }
 
+// getSSLCerts returns the SSL certificates referenced in Ingress rules
+func (ic *NGINXController) getSSLCerts() []*ingress.SSLCert {
+	var certs []*ingress.SSLCert
+	for _, item := range ic.sslCertTracker.List() {
+		if cert, ok := item.(*ingress.SSLCert); ok {
+			certs = append(certs, cert)
+		}
+	}
+	return certs
+}
+
 func (ic *NGINXController) checkSSLChainIssues() {
 	for _, secretName := range ic.sslCertTracker.ListKeys() {
 		s, _ := ic.sslCertTracker.Get(secretName)
@@ -185,9 +196,7 @@ func (ic *NGINXController) checkMissingSecrets() {
 			}
 
 			key := fmt.Sprintf("%v/%v", ing.Namespace, tls.SecretName)
-			if _, ok := ic.sslCertTracker.Get(key); !ok {
-				ic.syncSecret(key)
-			}
+			ic.checkMissingSecret(ing, key)
 		}
 
 		key, _ := parser.GetStringAnnotation("auth-tls-secret", ing)
@@ -195,8 +204,26 @@ func (ic *NGINXController) checkMissingSecrets() {
 			continue
 		}
 
-		if _, ok := ic.sslCertTracker.Get(key); !ok {
-			ic.syncSecret(key)
-		}
+		ic.checkMissingSecret(ing, key)
+	}
+}
+
+// checkMissingSecret tries to load a secret referenced by an ingress rule that
+// is not present in the local secret store and records a Warning event in the
+// Ingress if it cannot be loaded.
+func (ic *NGINXController) checkMissingSecret(ing *networking.Ingress, key string) {
+	if _, ok := ic.sslCertTracker.Get(key); ok {
+		return
+	}
+
+	ic.syncSecret(key)
+	if _, ok := ic.sslCertTracker.Get(key); ok {
+		return
+	}
+
+	if _, err := ic.listers.Secret.GetByName(key); err != nil {
+		ic.recordWarning(ing, reasonMissingSecret, "secret %v not found", key)
+		return
 	}
+	ic.recordWarning(ing, reasonMissingSecret, "secret %v does not contain a valid SSL certificate", key)
 }
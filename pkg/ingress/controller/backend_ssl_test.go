@@ -29,6 +29,7 @@ import (
 	"k8s.io/client-go/util/flowcontrol"
 
 	"github.com/stolostron/management-ingress/pkg/ingress"
+	"github.com/stolostron/management-ingress/pkg/ingress/metric"
 	"github.com/stolostron/management-ingress/pkg/ingress/store"
 	"github.com/stolostron/management-ingress/pkg/task"
 )
@@ -108,8 +109,9 @@ func buildGenericControllerForBackendSSL() *NGINXController {
 		cfg: &Configuration{
 			Client: buildSimpleClientSetForBackendSSL(),
 		},
-		listers:        buildListers(),
-		sslCertTracker: store.NewSSLCertTracker(),
+		listers:         buildListers(),
+		sslCertTracker:  store.NewSSLCertTracker(),
+		metricCollector: metric.NewDummyCollector(),
 	}
 
 	gc.syncQueue = task.NewTaskQueue(gc.syncIngress)
@@ -0,0 +1,76 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"github.com/golang/glog"
+
+	networking "k8s.io/api/networking/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/backendprotocol"
+)
+
+// splitCanaryIngresses returns the Ingresses that are not canaries and
+// the canary Ingresses, keeping the order of the original list
+func (n *NGINXController) splitCanaryIngresses(ings []*networking.Ingress) ([]*networking.Ingress, []*networking.Ingress) {
+	var primaries, canaries []*networking.Ingress
+	for _, ing := range ings {
+		if n.getIngressAnnotations(ing).Canary.Enabled {
+			canaries = append(canaries, ing)
+			continue
+		}
+		primaries = append(primaries, ing)
+	}
+	return primaries, canaries
+}
+
+// addCanaryBackend configures the backend of a canary Ingress path as the
+// alternative backend of the location with the same path in the server.
+// A canary never creates a location, the path is ignored when no other
+// Ingress defines it. The upstream is selected using the names of the
+// upstream blocks, so backends of ExternalName services are not supported.
+// Both backends share the proxy_pass of the location, so the canary must use
+// the protocol of the primary backend.
+func (n *NGINXController) addCanaryBackend(server *ingress.Server, ing *networking.Ingress, anns *annotations.Ingress,
+	upstreams map[string]*ingress.Backend, ups *ingress.Backend, nginxPath string, pathType networking.PathType) {
+	for _, loc := range server.Locations {
+		if loc.Path != nginxPath || isExactLocation(loc) != (pathType == networking.PathTypeExact) {
+			continue
+		}
+		if loc.Ingress == nil {
+			break
+		}
+		if !hasUpstreamServers(ups) {
+			glog.Warningf("canary ingress %v/%v upstream %v does not have active endpoints", ing.Namespace, ing.Name, ups.Name)
+			return
+		}
+		if primary := upstreams[loc.Backend]; ups.ExternalName != "" || (primary != nil && primary.ExternalName != "") {
+			glog.Warningf("canary ingress %v/%v location %v uses an ExternalName service, which is not supported", ing.Namespace, ing.Name, loc.Path)
+			return
+		}
+
+		if primary := upstreams[loc.Backend]; primary != nil && !sameBackendProtocol(primary, ups) {
+			glog.Warningf("canary ingress %v/%v location %v uses a backend protocol different from the one of the primary", ing.Namespace, ing.Name, loc.Path)
+			n.recordWarning(ing, reasonConflict, "path %v in host %v uses a backend protocol different from the one of ingress %v", nginxPath, server.Hostname, ingressKey(loc.Ingress))
+			return
+		}
+
+		glog.V(3).Infof("adding canary upstream %v of ingress %v/%v to location %v", ups.Name, ing.Namespace, ing.Name, loc.Path)
+		loc.CanaryBackend = ups.Name
+		loc.Canary = anns.Canary
+		return
+	}
+
+	glog.Warningf("canary ingress %v/%v path %v in server %v does not match the path of other ingress", ing.Namespace, ing.Name, nginxPath, server.Hostname)
+	n.recordWarning(ing, reasonCanaryWithoutPrimary, "path %v in host %v does not match the path of other ingress", nginxPath, server.Hostname)
+}
+
+// sameBackendProtocol checks if two backends are reached
+// with the same NGINX module and scheme
+func sameBackendProtocol(b1, b2 *ingress.Backend) bool {
+	return b1.Secure == b2.Secure &&
+		backendprotocol.IsGRPC(b1.BackendProtocol) == backendprotocol.IsGRPC(b2.BackendProtocol)
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"testing"
+
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/class"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+)
+
+func TestCanaryLocations(t *testing.T) {
+	ic := class.IngressClass
+	class.IngressClass = class.DefaultClass
+	defer func() {
+		class.IngressClass = ic
+	}()
+
+	testCases := []struct {
+		name          string
+		canaryPath    string
+		protocol      string
+		canaryBackend string
+		event         bool
+	}{
+		{"same path", "/foo", "", "default-bar-80", false},
+		{"path without primary", "/other", "", "", true},
+		{"different protocol", "/foo", "HTTPS", "", true},
+		{"same module and scheme", "/foo", "HTTP", "default-bar-80", false},
+	}
+
+	for _, tc := range testCases {
+		n := buildControllerForChecker(t, "/bin/true")
+		recorder := record.NewFakeRecorder(10)
+		n.recorder = recorder
+		addServiceForChecker(t, n, "foo")
+		addServiceForChecker(t, n, "bar")
+
+		anns := map[string]string{
+			parser.GetAnnotationWithPrefix("canary"):        "true",
+			parser.GetAnnotationWithPrefix("canary-weight"): "20",
+		}
+		if tc.protocol != "" {
+			anns[parser.GetAnnotationWithPrefix("backend-protocol")] = tc.protocol
+		}
+		canary := buildIngressForChecker(class.DefaultClass, anns)
+		canary.Name = "bar"
+		canary.Spec.Rules[0].HTTP.Paths[0].Path = tc.canaryPath
+		canary.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name = "bar"
+		primary := buildIngressForChecker(class.DefaultClass, nil)
+		n.extractAnnotations(canary)
+		n.extractAnnotations(primary)
+
+		// the canary is listed first to check it does not replace the primary
+		_, servers, _ := n.getBackendServers([]*networking.Ingress{canary, primary}, n.readConfig())
+
+		var locations int
+		for _, server := range servers {
+			for _, loc := range server.Locations {
+				if loc.Ingress == nil {
+					continue
+				}
+				locations++
+				if loc.Ingress != primary {
+					t.Errorf("%v: expected the location %v to belong to the primary ingress", tc.name, loc.Path)
+				}
+				if loc.Backend != "default-foo-80" {
+					t.Errorf("%v: expected the backend default-foo-80 but got %v", tc.name, loc.Backend)
+				}
+				if loc.CanaryBackend != tc.canaryBackend {
+					t.Errorf("%v: expected the canary backend %q but got %q", tc.name, tc.canaryBackend, loc.CanaryBackend)
+				}
+				if tc.canaryBackend != "" && loc.Canary.Weight != 20 {
+					t.Errorf("%v: expected the canary weight 20 but got %v", tc.name, loc.Canary.Weight)
+				}
+			}
+		}
+		if locations != 1 {
+			t.Errorf("%v: expected one location but got %v", tc.name, locations)
+		}
+
+		if tc.event != (len(recorder.Events) > 0) {
+			t.Errorf("%v: expected an event %v but got %v", tc.name, tc.event, len(recorder.Events))
+		}
+	}
+}
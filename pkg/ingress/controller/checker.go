@@ -0,0 +1,74 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+
+	networking "k8s.io/api/networking/v1"
+	cache_client "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/stolostron/management-ingress/pkg/ingress"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/class"
+)
+
+// CheckIngress verifies an Ingress before it is persisted. The annotations
+// are validated using all the parsers and the NGINX configuration that would
+// include the Ingress is tested running the command "nginx -t".
+// Ingresses that are not handled by this controller are always accepted.
+func (n *NGINXController) CheckIngress(ing *networking.Ingress) error {
+	if ing == nil {
+		return nil
+	}
+
+	if !class.IsValid(ing) {
+		glog.V(3).Infof("skipping validation of ingress %v/%v based on the ingress class", ing.Namespace, ing.Name)
+		return nil
+	}
+
+	if err := n.annotations.Validate(ing); err != nil {
+		return err
+	}
+
+	// the candidate configuration is built with its own annotation store
+	// so the running state is not modified. The Ingress might be
+	// rejected, so no events are recorded.
+	listers := *n.listers
+	listers.IngressAnnotation.Store = cache_client.NewStore(cache_client.DeletionHandlingMetaNamespaceKeyFunc)
+	for _, anns := range n.listers.IngressAnnotation.List() {
+		if err := listers.IngressAnnotation.Add(anns); err != nil {
+			return err
+		}
+	}
+	if err := listers.IngressAnnotation.Update(n.annotations.Extract(ing)); err != nil {
+		return err
+	}
+	candidate := n.newConfigurationBuilder(&listers, &record.FakeRecorder{})
+
+	key := fmt.Sprintf("%v/%v", ing.Namespace, ing.Name)
+	ingresses := []*networking.Ingress{}
+	for _, current := range n.getValidIngresses() {
+		if fmt.Sprintf("%v/%v", current.Namespace, current.Name) == key {
+			continue
+		}
+		ingresses = append(ingresses, current)
+	}
+	ingresses = append(ingresses, ing)
+	sortIngresses(ingresses)
+
+	cfg := n.readConfig()
+	upstreams, servers, _ := candidate.getBackendServers(ingresses, cfg)
+	content, err := candidate.t.Write(candidate.buildTemplateConfig(ingress.Configuration{
+		Backends: upstreams,
+		Servers:  servers,
+	}, cfg))
+	if err != nil {
+		return err
+	}
+
+	return candidate.testTemplate(content)
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/stolostron/management-ingress/pkg/file"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/class"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	ngx_config "github.com/stolostron/management-ingress/pkg/ingress/controller/config"
+	ngx_template "github.com/stolostron/management-ingress/pkg/ingress/controller/template"
+	ing_errors "github.com/stolostron/management-ingress/pkg/ingress/errors"
+	"github.com/stolostron/management-ingress/pkg/ingress/metric"
+	"github.com/stolostron/management-ingress/pkg/ingress/store"
+	"github.com/stolostron/management-ingress/pkg/task"
+)
+
+func buildControllerForChecker(t *testing.T, binary string) *NGINXController {
+	tmpl, err := ngx_template.NewTemplate("../../../rootfs/opt/ibm/router/nginx/template/nginx.tmpl", &file.DefaultFs{})
+	if err != nil {
+		t.Fatalf("unexpected error loading template: %v", err)
+	}
+
+	n := &NGINXController{
+		binary: binary,
+		cfg: &Configuration{
+			ListenPorts: &ngx_config.ListenPorts{HTTP: 8080, HTTPS: 8443, Status: 10246},
+		},
+		configmap:       &apiv1.ConfigMap{},
+		sslCertTracker:  store.NewSSLCertTracker(),
+		metricCollector: metric.NewDummyCollector(),
+		recorder:        &record.FakeRecorder{},
+		conflicts:       newConflictTracker(),
+		t:               tmpl,
+	}
+	n.syncQueue = task.NewTaskQueue(n.syncIngress)
+	n.listers = newStaticListers()
+	n.annotations = annotations.NewAnnotationExtractor(n)
+	return n
+}
+
+func addServiceForChecker(t *testing.T, n *NGINXController, name string) {
+	err := n.listers.Service.Add(&apiv1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceDefault},
+		Spec: apiv1.ServiceSpec{
+			ClusterIP: "10.0.0.1",
+			Ports:     []apiv1.ServicePort{{Port: 80}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error adding service: %v", err)
+	}
+}
+
+func buildIngressForChecker(ingClass string, anns map[string]string) *networking.Ingress {
+	ingAnns := map[string]string{class.IngressKey: ingClass}
+	for k, v := range anns {
+		ingAnns[k] = v
+	}
+	return &networking.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "foo",
+			Namespace:   metav1.NamespaceDefault,
+			Annotations: ingAnns,
+		},
+		Spec: networking.IngressSpec{
+			Rules: []networking.IngressRule{{
+				IngressRuleValue: networking.IngressRuleValue{
+					HTTP: &networking.HTTPIngressRuleValue{
+						Paths: []networking.HTTPIngressPath{{
+							Path: "/foo",
+							Backend: networking.IngressBackend{
+								Service: &networking.IngressServiceBackend{
+									Name: "foo",
+									Port: networking.ServiceBackendPort{Number: 80},
+								},
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+}
+
+func TestCheckIngress(t *testing.T) {
+	ic := class.IngressClass
+	class.IngressClass = class.DefaultClass
+	defer func() {
+		class.IngressClass = ic
+	}()
+
+	invalidAnns := map[string]string{parser.GetAnnotationWithPrefix("auth-type"): "basic"}
+
+	testCases := []struct {
+		name    string
+		binary  string
+		ing     *networking.Ingress
+		invalid bool
+		err     bool
+	}{
+		{"nil ingress", "/bin/false", nil, false, false},
+		{"other ingress class", "/bin/false", buildIngressForChecker("other", invalidAnns), false, false},
+		{"invalid annotation", "/bin/true", buildIngressForChecker(class.DefaultClass, invalidAnns), true, true},
+		{"valid configuration", "/bin/true", buildIngressForChecker(class.DefaultClass, nil), false, false},
+		{"invalid configuration", "/bin/false", buildIngressForChecker(class.DefaultClass, nil), false, true},
+	}
+
+	for _, tc := range testCases {
+		n := buildControllerForChecker(t, tc.binary)
+		err := n.CheckIngress(tc.ing)
+		if tc.err != (err != nil) {
+			t.Errorf("%v: unexpected result %v", tc.name, err)
+		}
+		if tc.invalid && !ing_errors.IsInvalidContent(err) {
+			t.Errorf("%v: expected an invalid content error but returned %v", tc.name, err)
+		}
+		if items := n.listers.IngressAnnotation.List(); len(items) != 0 {
+			t.Errorf("%v: expected no changes in the annotation store but returned %v", tc.name, items)
+		}
+	}
+}
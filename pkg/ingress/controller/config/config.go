@@ -12,6 +12,7 @@ restricted by GSA ADP Schedule Contract with IBM Corp.
 package config
 
 import (
+	"fmt"
 	"net"
 	"runtime"
 	"strconv"
@@ -35,7 +36,7 @@ const (
 	// that tell browsers that it should only be communicated with using HTTPS, instead of using HTTP.
 	// https://developer.mozilla.org/en-US/docs/Web/Security/HTTP_strict_transport_security
 	// max-age is the time, in seconds, that the browser should remember that this site is only to be accessed using HTTPS.
-	hstsMaxAge = "15724800"
+	hstsMaxAge = "63072000"
 
 	gzipTypes = "application/atom+xml application/javascript application/x-javascript application/json application/rss+xml application/vnd.ms-fontobject application/x-font-ttf application/x-web-app-manifest+json application/xhtml+xml application/xml font/opentype image/svg+xml image/x-icon text/css text/plain text/x-component"
 
@@ -77,7 +78,7 @@ const (
 
 // Configuration represents the content of nginx.conf file
 type Configuration struct {
-	// Sets the name of the configmap that contains the headers to pass to the client
+	// Sets the namespace/name of the configmap that contains the headers to pass to the client
 	AddHeaders string `json:"add-headers,omitempty"`
 
 	// AllowBackendServerHeader enables the return of the header Server from the backend
@@ -197,6 +198,25 @@ type Configuration struct {
 	// Enables or disables the preload attribute in HSTS feature
 	HSTSPreload bool `json:"hsts-preload,omitempty"`
 
+	// XFrameOptions sets the header X-Frame-Options of the responses, DENY or SAMEORIGIN.
+	// The header is not added when it is empty, so the frame-ancestors directive of
+	// the content-security-policy decides which pages can embed the responses
+	// Default: SAMEORIGIN
+	XFrameOptions string `json:"x-frame-options"`
+
+	// XContentTypeOptions sets the header X-Content-Type-Options of the responses
+	// Default: nosniff
+	XContentTypeOptions string `json:"x-content-type-options"`
+
+	// XXSSProtection sets the header X-XSS-Protection of the responses
+	// Default: 1; mode=block
+	XXSSProtection string `json:"x-xss-protection"`
+
+	// ContentSecurityPolicy sets the header Content-Security-Policy of the responses
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/CSP
+	// By default the header is not added
+	ContentSecurityPolicy string `json:"content-security-policy,omitempty"`
+
 	// Time during which a keep-alive client connection will stay open on the server side.
 	// The zero value disables keep-alive client connections
 	// http://nginx.org/en/docs/http/ngx_http_core_module.html#keepalive_timeout
@@ -233,13 +253,27 @@ type Configuration struct {
 	// http://nginx.org/en/docs/http/ngx_http_map_module.html#map_hash_bucket_size
 	MapHashBucketSize int `json:"map-hash-bucket-size,omitempty"`
 
+	// AllowlistSourceRange contains the CIDRs of the clients allowed to access the
+	// locations that do not define the annotation allowlist-source-range.
+	// By default all the clients are allowed
+	AllowlistSourceRange []string `json:"allowlist-source-range,omitempty"`
+
+	// DenylistSourceRange contains the CIDRs of the clients denied to access the
+	// locations that do not define the annotation denylist-source-range
+	DenylistSourceRange []string `json:"denylist-source-range,omitempty"`
+
 	// If UseProxyProtocol is enabled ProxyRealIPCIDR defines the default the IP/network address
 	// of your external load balancer
 	ProxyRealIPCIDR []string `json:"proxy-real-ip-cidr,omitempty"`
 
-	// Sets the name of the configmap that contains the headers to pass to the backend
+	// Sets the namespace/name of the configmap that contains the headers to pass to the backend
 	ProxySetHeaders string `json:"proxy-set-headers,omitempty"`
 
+	// ProxyHideHeaders contains the headers of the upstream responses that are not
+	// passed to the clients, like the ones that reveal the versions of the backends
+	// http://nginx.org/en/docs/http/ngx_http_proxy_module.html#proxy_hide_header
+	ProxyHideHeaders []string `json:"proxy-hide-headers,omitempty"`
+
 	// Maximum size of the server names hash tables used in server names, map directive’s values,
 	// MIME types, names of request header strings, etcd.
 	// http://nginx.org/en/docs/hash.html
@@ -323,11 +357,19 @@ type Configuration struct {
 	// http://nginx.org/en/docs/http/ngx_http_gzip_module.html
 	UseGzip bool `json:"use-gzip,omitempty"`
 
-	// Enables or disables the use of the NGINX Brotli Module for compression
+	// Gzip compression level that will be used, from 1 to 9
+	// http://nginx.org/en/docs/http/ngx_http_gzip_module.html#gzip_comp_level
+	// Default: 5
+	GzipLevel int `json:"gzip-level,omitempty"`
+
+	// Enables or disables the use of the NGINX Brotli Module for compression.
+	// The dynamic module is loaded from /etc/nginx/modules, so it must be
+	// installed in the image before enabling it
 	// https://github.com/google/ngx_brotli
+	// Default: false
 	EnableBrotli bool `json:"enable-brotli,omitempty"`
 
-	// Brotli Compression Level that will be used
+	// Brotli Compression Level that will be used, from 0 to 11
 	BrotliLevel int `json:"brotli-level,omitempty"`
 
 	// MIME Types that will be compressed on-the-fly using Brotli module
@@ -384,6 +426,11 @@ type Configuration struct {
 	// Default: 1
 	ProxyStreamResponses int `json:"proxy-stream-responses,omitempty"`
 
+	// CustomHTTPErrors are the status codes of the upstream responses replaced
+	// with the error pages of the default-backend annotation when the Ingress
+	// does not use the annotation custom-http-errors
+	CustomHTTPErrors []int `json:"custom-http-errors,omitempty"`
+
 	// Sets the ipv4 addresses on which the server will accept requests.
 	BindAddressIpv4 []string `json:"bind-address-ipv4,omitempty"`
 
@@ -422,11 +469,28 @@ type Configuration struct {
 	// LocationSnippet adds custom configuration to all the locations in the nginx configuration
 	LocationSnippet string `json:"location-snippet"`
 
+	// HostOwnership maps hostnames and wildcard domains to the namespaces allowed
+	// to use them, with the format <host>=<namespace>,<host>=<namespace>
+	// Example: *.apps.example.com=team-a,console.example.com=openshift-console
+	// By default any namespace can use any host
+	HostOwnership string `json:"host-ownership,omitempty"`
+
 	// HTTPRedirectCode sets the HTTP status code to be used in redirects.
 	// Supported codes are 301,302,307 and 308
 	// Default: 308
 	HTTPRedirectCode int `json:"http-redirect-code"`
 
+	// SSLRedirect redirects the HTTP requests to HTTPS in the servers with
+	// a certificate when the Ingress does not define the annotation ssl-redirect
+	// Default: false
+	SSLRedirect bool `json:"ssl-redirect"`
+
+	// ForceSSLRedirect redirects the HTTP requests to HTTPS even in the
+	// servers without a certificate, when TLS is terminated in front of
+	// NGINX, if the Ingress does not define the annotation force-ssl-redirect
+	// Default: false
+	ForceSSLRedirect bool `json:"force-ssl-redirect"`
+
 	// Name server/s used to resolve names of upstream servers into IP addresses.
 	// The file /etc/resolv.conf is used as DNS resolution configuration.
 	Resolver []net.IP
@@ -446,13 +510,17 @@ type TemplateConfig struct {
 	IsIPV6Enabled   bool
 	RedirectServers map[string]string
 	ListenPorts     *ListenPorts
+	// DynamicConfiguration indicates if the endpoints of the upstreams
+	// are configured by the controller in the Lua balancer
+	DynamicConfiguration bool
 }
 
 // ListenPorts describe the ports required to run the
 // NGINX Ingress controller
 type ListenPorts struct {
-	HTTP  int
-	HTTPS int
+	HTTP   int
+	HTTPS  int
+	Status int
 }
 
 // NewDefault returns the default nginx configuration
@@ -489,7 +557,11 @@ func NewDefault() Configuration {
 		HSTSIncludeSubdomains:        true,
 		HSTSMaxAge:                   hstsMaxAge,
 		HSTSPreload:                  false,
+		XFrameOptions:                "SAMEORIGIN",
+		XContentTypeOptions:          "nosniff",
+		XXSSProtection:               "1; mode=block",
 		IgnoreInvalidHeaders:         true,
+		GzipLevel:                    5,
 		GzipTypes:                    gzipTypes,
 		KeepAlive:                    75,
 		KeepAliveRequests:            100,
@@ -513,7 +585,7 @@ func NewDefault() Configuration {
 		SSLSessionCacheSize:          sslSessionCacheSize,
 		SSLSessionTickets:            true,
 		SSLSessionTimeout:            sslSessionTimeout,
-		EnableBrotli:                 true,
+		EnableBrotli:                 false,
 		UseGzip:                      true,
 		WorkerProcesses:              strconv.Itoa(workerProcesses),
 		WorkerShutdownTimeout:        "10s",
@@ -542,3 +614,27 @@ func NewDefault() Configuration {
 
 	return cfg
 }
+
+// SecurityHeaders returns the security headers added to the responses
+// with the values of the configuration
+func (cfg Configuration) SecurityHeaders() ingress.SecurityHeaders {
+	headers := ingress.SecurityHeaders{
+		XFrameOptions:         cfg.XFrameOptions,
+		XContentTypeOptions:   cfg.XContentTypeOptions,
+		XXSSProtection:        cfg.XXSSProtection,
+		ContentSecurityPolicy: cfg.ContentSecurityPolicy,
+	}
+
+	if cfg.HSTS {
+		hsts := fmt.Sprintf("max-age=%v", cfg.HSTSMaxAge)
+		if cfg.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if cfg.HSTSPreload {
+			hsts += "; preload"
+		}
+		headers.StrictTransportSecurity = hsts
+	}
+
+	return headers
+}
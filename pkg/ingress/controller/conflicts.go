@@ -0,0 +1,128 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/golang/glog"
+
+	networking "k8s.io/api/networking/v1"
+)
+
+// conflictsPath is the location of the metrics server
+// that lists the conflicts of the running configuration
+const conflictsPath = "/debug/conflicts"
+
+// ingressConflict describes a host and path defined by more than one
+// Ingress. The location of the winner is included in the configuration.
+type ingressConflict struct {
+	Host   string `json:"host"`
+	Path   string `json:"path"`
+	Winner string `json:"winner"`
+	Loser  string `json:"loser"`
+	// loser is the Ingress that receives the event of the conflict
+	loser *networking.Ingress
+}
+
+// conflictTracker contains the conflicts of the running configuration
+type conflictTracker struct {
+	lock      sync.RWMutex
+	conflicts []ingressConflict
+}
+
+// newConflictTracker creates a tracker without conflicts
+func newConflictTracker() *conflictTracker {
+	return &conflictTracker{
+		conflicts: []ingressConflict{},
+	}
+}
+
+// set replaces the conflicts of the running configuration and
+// returns the ones that were not included in the previous conflicts
+func (t *conflictTracker) set(conflicts []ingressConflict) []ingressConflict {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	previous := map[string]bool{}
+	for _, c := range t.conflicts {
+		previous[c.key()] = true
+	}
+
+	added := []ingressConflict{}
+	for _, c := range conflicts {
+		if !previous[c.key()] {
+			added = append(added, c)
+		}
+	}
+
+	t.conflicts = conflicts
+	return added
+}
+
+// key returns the host, path and Ingresses of a conflict
+func (c ingressConflict) key() string {
+	return fmt.Sprintf("%v %v %v %v", c.Host, c.Path, c.Winner, c.Loser)
+}
+
+// ServeHTTP returns the conflicts of the running configuration in JSON format
+func (t *conflictTracker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(t.conflicts); err != nil {
+		glog.Errorf("unexpected error writing the ingress conflicts: %v", err)
+	}
+}
+
+// sortIngresses sorts a list of Ingresses by creation time, the oldest
+// first, using the namespace and name when the creation time is the same.
+// An Ingress without creation time has not been created yet and is the
+// newest one.
+func sortIngresses(ings []*networking.Ingress) {
+	sort.SliceStable(ings, func(i, j int) bool {
+		ti := ings[i].CreationTimestamp
+		tj := ings[j].CreationTimestamp
+		if ti.IsZero() != tj.IsZero() {
+			return tj.IsZero()
+		}
+		if !ti.Equal(&tj) {
+			return ti.Before(&tj)
+		}
+		return ingressKey(ings[i]) < ingressKey(ings[j])
+	})
+}
+
+// ingressKey returns the namespace/name of an Ingress
+func ingressKey(ing *networking.Ingress) string {
+	return fmt.Sprintf("%v/%v", ing.Namespace, ing.Name)
+}
+
+// newConflict returns the conflict of a host and path defined by two
+// Ingresses. The location of the winner, which is the oldest Ingress, is kept.
+func newConflict(host, path string, winner, loser *networking.Ingress) ingressConflict {
+	return ingressConflict{
+		Host:   host,
+		Path:   path,
+		Winner: ingressKey(winner),
+		Loser:  ingressKey(loser),
+		loser:  loser,
+	}
+}
+
+// setConflicts replaces the conflicts of the running configuration, and
+// reports the new ones with a Warning event in the Ingress that loses them.
+// It is called after the configuration is applied, so a conflict of a
+// configuration that fails to load is not reported.
+func (n *NGINXController) setConflicts(conflicts []ingressConflict) {
+	for _, c := range n.conflicts.set(conflicts) {
+		glog.Warningf("ingress %v defines host %v path %v, which is already defined by ingress %v", c.Loser, c.Host, c.Path, c.Winner)
+		n.recordWarning(c.loser, reasonConflict, "host %v path %v is already defined by ingress %v", c.Host, c.Path, c.Winner)
+	}
+}
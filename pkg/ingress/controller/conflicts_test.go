@@ -0,0 +1,187 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/stolostron/management-ingress/pkg/ingress"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/authtls"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/class"
+	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
+)
+
+func TestSortIngresses(t *testing.T) {
+	now := time.Now()
+	older := metav1.NewTime(now.Add(-time.Hour))
+	newer := metav1.NewTime(now)
+
+	build := func(namespace, name string, created metav1.Time) *networking.Ingress {
+		return &networking.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, CreationTimestamp: created}}
+	}
+
+	ings := []*networking.Ingress{
+		build("a", "not-created", metav1.Time{}),
+		build("b", "newer", newer),
+		build("b", "older", older),
+		build("a", "newer", newer),
+	}
+	sortIngresses(ings)
+
+	var keys []string
+	for _, ing := range ings {
+		keys = append(keys, ingressKey(ing))
+	}
+	expected := []string{"b/older", "a/newer", "b/newer", "a/not-created"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected the order %v but got %v", expected, keys)
+	}
+}
+
+func TestIngressConflicts(t *testing.T) {
+	ic := class.IngressClass
+	class.IngressClass = class.DefaultClass
+	defer func() {
+		class.IngressClass = ic
+	}()
+
+	n := buildControllerForChecker(t, "/bin/true")
+	recorder := record.NewFakeRecorder(10)
+	n.recorder = recorder
+	addServiceForChecker(t, n, "foo")
+
+	older := buildIngressForChecker(class.DefaultClass, nil)
+	older.Name = "older"
+	older.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+	newer := buildIngressForChecker(class.DefaultClass, nil)
+	newer.Name = "newer"
+	newer.CreationTimestamp = metav1.NewTime(time.Now())
+	for _, ing := range []*networking.Ingress{older, newer} {
+		if err := n.listers.Ingress.Add(ing); err != nil {
+			t.Fatalf("unexpected error adding ingress: %v", err)
+		}
+		n.extractAnnotations(ing)
+	}
+
+	pcfg, conflicts := n.buildConfiguration(n.getValidIngresses(), n.readConfig())
+
+	for _, server := range pcfg.Servers {
+		for _, loc := range server.Locations {
+			if loc.Path == "/foo" && loc.Ingress != older {
+				t.Errorf("expected the location /foo to belong to the older ingress but got %v", ingressKey(loc.Ingress))
+			}
+		}
+	}
+
+	expected := []ingressConflict{{Host: defServerName, Path: "/foo", Winner: "default/older", Loser: "default/newer", loser: newer}}
+	if !reflect.DeepEqual(conflicts, expected) {
+		t.Errorf("expected the conflicts %v but got %v", expected, conflicts)
+	}
+
+	// the conflicts are reported when the configuration is applied
+	if len(recorder.Events) != 0 {
+		t.Errorf("expected no events before the configuration is applied but got %v", len(recorder.Events))
+	}
+	n.setConflicts(conflicts)
+	if len(recorder.Events) != 1 {
+		t.Fatalf("expected one event but got %v", len(recorder.Events))
+	}
+	event := <-recorder.Events
+	if !strings.HasPrefix(event, "Warning Conflict host _ path /foo is already defined by ingress default/older") {
+		t.Errorf("unexpected event %q", event)
+	}
+
+	// the events are recorded only when the conflicts change
+	_, conflicts = n.buildConfiguration(n.getValidIngresses(), n.readConfig())
+	n.setConflicts(conflicts)
+	if len(recorder.Events) != 0 {
+		t.Errorf("expected no events for the same conflicts but got %v", len(recorder.Events))
+	}
+
+	expected[0].loser = nil
+	w := httptest.NewRecorder()
+	n.conflicts.ServeHTTP(w, httptest.NewRequest("GET", conflictsPath, nil))
+	var served []ingressConflict
+	if err := json.Unmarshal(w.Body.Bytes(), &served); err != nil {
+		t.Fatalf("unexpected error decoding the conflicts: %v", err)
+	}
+	if !reflect.DeepEqual(served, expected) {
+		t.Errorf("expected the conflicts %v but got %v", expected, served)
+	}
+}
+
+func TestCertificateAuthConflicts(t *testing.T) {
+	ic := class.IngressClass
+	class.IngressClass = class.DefaultClass
+	defer func() {
+		class.IngressClass = ic
+	}()
+
+	n := buildControllerForChecker(t, "/bin/true")
+	recorder := record.NewFakeRecorder(10)
+	n.recorder = recorder
+	addServiceForChecker(t, n, "foo")
+
+	first := authtls.Config{
+		AuthSSLCert:     resolver.AuthSSLCert{Secret: "default/first-ca", CAFileName: "/etc/ingress-controller/ssl/ca-default-first-ca.pem"},
+		VerifyClient:    "on",
+		ValidationDepth: 1,
+	}
+	second := first
+	second.AuthSSLCert = resolver.AuthSSLCert{Secret: "default/second-ca", CAFileName: "/etc/ingress-controller/ssl/ca-default-second-ca.pem"}
+
+	testCases := []struct {
+		name string
+		auth authtls.Config
+	}{
+		{"older", first},
+		{"same", first},
+		{"without", authtls.Config{}},
+		{"newer", second},
+	}
+
+	var ings []*networking.Ingress
+	for i, tc := range testCases {
+		ing := buildIngressForChecker(class.DefaultClass, nil)
+		ing.Name = tc.name
+		ing.CreationTimestamp = metav1.NewTime(time.Now().Add(time.Duration(i-len(testCases)) * time.Minute))
+		ing.Spec.Rules[0].Host = "foo.example.com"
+		ing.Spec.Rules[0].HTTP.Paths[0].Path = "/" + tc.name
+		n.extractAnnotations(ing)
+		n.getIngressAnnotations(ing).CertificateAuth = tc.auth
+		ings = append(ings, ing)
+	}
+
+	_, servers, _ := n.getBackendServers(ings, n.readConfig())
+
+	var server *ingress.Server
+	for _, s := range servers {
+		if s.Hostname == "foo.example.com" {
+			server = s
+		}
+	}
+	if server == nil {
+		t.Fatalf("expected a server for host foo.example.com")
+	}
+	if !(&server.CertificateAuth).Equal(&first) {
+		t.Errorf("expected the client certificate authentication of the older ingress but got %+v", server.CertificateAuth)
+	}
+
+	if len(recorder.Events) != 1 {
+		t.Fatalf("expected one event but got %v", len(recorder.Events))
+	}
+	event := <-recorder.Events
+	if !strings.HasPrefix(event, "Warning Conflict host foo.example.com already uses a different client certificate authentication") {
+		t.Errorf("unexpected event %q", event)
+	}
+}
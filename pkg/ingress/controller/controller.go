@@ -23,6 +23,7 @@ import (
 	networking "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
 	clientset "k8s.io/client-go/kubernetes"
 
 	"github.com/stolostron/management-ingress/pkg/ingress"
@@ -31,6 +32,7 @@ import (
 	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
 	"github.com/stolostron/management-ingress/pkg/ingress/annotations/proxy"
 	"github.com/stolostron/management-ingress/pkg/ingress/annotations/rewrite"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/sourcerange"
 	ngx_config "github.com/stolostron/management-ingress/pkg/ingress/controller/config"
 	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
 	"github.com/stolostron/management-ingress/pkg/task"
@@ -63,6 +65,18 @@ type Configuration struct {
 	ListenPorts *ngx_config.ListenPorts
 
 	SyncRateLimit float32
+
+	MetricsPort int
+
+	DynamicConfiguration bool
+
+	LastGoodConfigPath string
+
+	HostOwnership string
+
+	ValidationWebhook         string
+	ValidationWebhookCertPath string
+	ValidationWebhookKeyPath  string
 }
 
 // SetForceReload sets if the ingress controller should be reloaded or not
@@ -75,6 +89,11 @@ func (n *NGINXController) SetForceReload(shouldReload bool) {
 	}
 }
 
+// isForceReload returns true if the next sync must reload NGINX
+func (n *NGINXController) isForceReload() bool {
+	return atomic.LoadInt32(&n.forceReload) != 0
+}
+
 // sync collects all the pieces required to assemble the configuration file and
 // then sends the content to the backend (OnUpdate) receiving the populated
 // template as response reloading the backend if is required.
@@ -85,6 +104,11 @@ func (n *NGINXController) syncIngress(item interface{}) error {
 		return nil
 	}
 
+	start := time.Now()
+	defer func() {
+		n.metricCollector.ObserveSyncDuration(time.Since(start))
+	}()
+
 	if element, ok := item.(task.Element); ok {
 		if name, ok := element.Key.(string); ok {
 			if obj, exists, _ := n.listers.Ingress.GetByKey(name); exists {
@@ -94,46 +118,94 @@ func (n *NGINXController) syncIngress(item interface{}) error {
 		}
 	}
 
-	// Sort ingress rules using the ResourceVersion field
-	ings := n.listers.Ingress.List()
-	sort.SliceStable(ings, func(i, j int) bool {
-		ir := ings[i].(*networking.Ingress).ResourceVersion
-		jr := ings[j].(*networking.Ingress).ResourceVersion
-		return ir < jr
-	})
-
-	// filter ingress rules
-	var ingresses []*networking.Ingress
-	for _, ingIf := range ings {
-		ing := ingIf.(*networking.Ingress)
-		if !class.IsValid(ing) {
-			continue
-		}
-
-		ingresses = append(ingresses, ing)
+	if n.isForceReload() {
+		// changes in the configuration can fix the excluded Ingresses
+		n.excludedIngresses = nil
 	}
 
-	upstreams, servers := n.getBackendServers(ingresses)
+	ings, excluded := n.excludeIngresses(n.getValidIngresses())
+	n.metricCollector.SetExcludedIngresses(excluded)
 
-	pcfg := ingress.Configuration{
-		Backends: upstreams,
-		Servers:  servers,
-	}
+	// the configuration is read once and used by all the Ingresses
+	cfg := n.readConfig()
+	pcfg, conflicts := n.buildConfiguration(ings, cfg)
+
+	n.metricCollector.SetSSLExpireTime(n.getSSLCerts())
 
-	if n.runningConfig.Equal(&pcfg) {
+	// the changes of the configuration ConfigMaps that are not part of the
+	// servers and upstreams, like the headers of the responses, force a reload
+	if !n.isForceReload() && n.runningConfig.Equal(&pcfg) {
 		glog.V(3).Infof("skipping backend reload (no changes detected)")
 		return nil
 	}
 
+	if n.cfg.DynamicConfiguration && !n.isForceReload() && n.runningConfig.EqualWithoutEndpoints(&pcfg) {
+		glog.Infof("dynamic reconfiguration of the backend endpoints required")
+		err := configureBackends(n.backendsConfigurationURL(), pcfg.Backends)
+		if err == nil {
+			glog.Infof("backend endpoints successfully updated without reload")
+			n.metricCollector.SetConfiguration(&pcfg)
+			n.runningConfig = &pcfg
+			n.setConflicts(conflicts)
+			return nil
+		}
+		glog.Warningf("unexpected error updating the backend endpoints, reloading instead: %v", err)
+	}
+
 	glog.Infof("backend reload required")
 
-	err := n.OnUpdate(pcfg)
+	err := n.OnUpdate(pcfg, cfg)
+	if _, ok := err.(invalidConfigurationError); ok && len(ings) > 0 {
+		glog.Warningf("invalid NGINX configuration, looking for the ingresses that cause the failure: \n%v", err)
+		invalid, ferr := n.findInvalidIngresses(ings, cfg)
+		if ferr != nil {
+			glog.Errorf("unexpected error looking for invalid ingresses: %v", ferr)
+		} else if len(invalid) > 0 {
+			for _, ing := range invalid {
+				glog.Warningf("excluding ingress %v/%v from the NGINX configuration", ing.Namespace, ing.Name)
+				n.excludedIngresses[fmt.Sprintf("%v/%v", ing.Namespace, ing.Name)] = ing.ResourceVersion
+				n.recordWarning(ing, reasonExcluded, "Ingress excluded from the NGINX configuration because it generates an invalid configuration: %v", err)
+			}
+			excluded = append(excluded, invalid...)
+			n.metricCollector.SetExcludedIngresses(excluded)
+
+			ings = removeIngresses(ings, invalid)
+			pcfg, conflicts = n.buildConfiguration(ings, cfg)
+			if !n.isForceReload() && n.runningConfig.Equal(&pcfg) {
+				glog.V(3).Infof("skipping backend reload (no changes detected after excluding ingresses)")
+				return nil
+			}
+			err = n.OnUpdate(pcfg, cfg)
+		}
+	}
 	if err != nil {
+		n.metricCollector.IncReloadErrorCount()
 		glog.Errorf("unexpected failure restarting the backend: \n%v", err)
+		n.recordIngressesEvent(ings, apiv1.EventTypeWarning, reasonReloadFailed, "NGINX configuration reload failed: %v", err)
 		return err
 	}
 
 	glog.Infof("ingress backend successfully reloaded...")
+	n.metricCollector.IncReloadCount()
+	n.metricCollector.SetConfiguration(&pcfg)
+	n.recordIngressesEvent(ings, apiv1.EventTypeNormal, reasonSynced, "NGINX configuration reloaded")
+	n.setConflicts(conflicts)
+
+	if n.cfg.DynamicConfiguration {
+		// the endpoints rendered in the configuration are used until the
+		// Lua balancer receives them, which replaces the ones sent before
+		// the reload. The new workers may take a moment to start.
+		err := wait.PollImmediate(dynamicConfigurationInterval, dynamicConfigurationTimeout, func() (bool, error) {
+			if err := configureBackends(n.backendsConfigurationURL(), pcfg.Backends); err != nil {
+				glog.V(2).Infof("unexpected error configuring the backend endpoints: %v", err)
+				return false, nil
+			}
+			return true, nil
+		})
+		if err != nil {
+			glog.Errorf("unexpected error configuring the backend endpoints after the reload: %v", err)
+		}
+	}
 
 	n.runningConfig = &pcfg
 	n.SetForceReload(false)
@@ -141,6 +213,34 @@ func (n *NGINXController) syncIngress(item interface{}) error {
 	return nil
 }
 
+// buildConfiguration returns the configuration generated with a list of
+// Ingresses and the conflicts between them
+func (n *NGINXController) buildConfiguration(ings []*networking.Ingress, cfg ngx_config.Configuration) (ingress.Configuration, []ingressConflict) {
+	upstreams, servers, conflicts := n.getBackendServers(ings, cfg)
+	return ingress.Configuration{
+		Backends: upstreams,
+		Servers:  servers,
+	}, conflicts
+}
+
+// getValidIngresses returns the Ingress rules handled by this controller
+// sorted by creation time, the oldest first
+func (n *NGINXController) getValidIngresses() []*networking.Ingress {
+	// filter ingress rules
+	var ingresses []*networking.Ingress
+	for _, ingIf := range n.listers.Ingress.List() {
+		ing := ingIf.(*networking.Ingress)
+		if !class.IsValid(ing) {
+			continue
+		}
+
+		ingresses = append(ingresses, ing)
+	}
+
+	sortIngresses(ingresses)
+	return ingresses
+}
+
 // readSecrets extracts information about secrets from an Ingress rule
 func (n *NGINXController) readSecrets(ing *networking.Ingress) {
 	for _, tls := range ing.Spec.TLS {
@@ -181,30 +281,33 @@ func (n *NGINXController) getKubernetesUpstream() *ingress.Backend {
 	upstream.ClusterIP = svc.Spec.ClusterIP
 	upstream.Port = intstr.FromInt(443)
 	upstream.Secure = true
+	upstream.Endpoints = getEndpoints(svc, upstream.Port, n.listers.Endpoint)
 	return upstream
 }
 
 // createUpstreams creates the NGINX upstreams for each service referenced in
-// Ingress rules. The servers inside the upstream are endpoints.
+// Ingress rules. The servers inside the upstream are the pod endpoints of the
+// service, using the ClusterIP only when no endpoint is ready.
 func (n *NGINXController) createUpstreams(data []*networking.Ingress, ku *ingress.Backend) map[string]*ingress.Backend {
 	upstreams := make(map[string]*ingress.Backend)
 	upstreams[kubernetesUpstreamName] = ku
+	affinityOwners := make(map[string]*networking.Ingress)
 
 	for _, ing := range data {
 		anns := n.getIngressAnnotations(ing)
 
 		var defBackend string
 		if ing.Spec.DefaultBackend != nil && ing.Spec.DefaultBackend.Service != nil {
-			defBackend = fmt.Sprintf("%v-%v-%v",
-				ing.GetNamespace(),
-				ing.Spec.DefaultBackend.Service.Name,
-				fmt.Sprintf("%d", ing.Spec.DefaultBackend.Service.Port.Number))
+			defBackend = upstreamName(ing.GetNamespace(), ing.Spec.DefaultBackend.Service)
 
 			glog.V(3).Infof("creating upstream %v", defBackend)
 			upstreams[defBackend] = newUpstream(defBackend)
 			if !upstreams[defBackend].Secure {
 				upstreams[defBackend].Secure = anns.SecureUpstream.Secure
 			}
+			if upstreams[defBackend].BackendProtocol == "" {
+				upstreams[defBackend].BackendProtocol = anns.BackendProtocol
+			}
 			if upstreams[defBackend].SecureCACert.Secret == "" {
 				upstreams[defBackend].SecureCACert = anns.SecureUpstream.CACert
 			}
@@ -214,38 +317,46 @@ func (n *NGINXController) createUpstreams(data []*networking.Ingress, ku *ingres
 			if upstreams[defBackend].ClientCACert.Secret == "" {
 				upstreams[defBackend].ClientCACert = anns.SecureUpstream.ClientCACert
 			}
+			n.setSessionAffinity(upstreams[defBackend], ing, anns.SessionAffinity, affinityOwners)
 		}
 
+		n.createErrorPagesUpstream(upstreams, ing, anns)
+
 		for _, rule := range ing.Spec.Rules {
 			if rule.HTTP == nil {
 				continue
 			}
 
 			for _, path := range rule.HTTP.Paths {
-				name := fmt.Sprintf("%v-%v-%v",
-					ing.GetNamespace(),
-					path.Backend.Service.Name,
-					fmt.Sprintf("%d", path.Backend.Service.Port.Number))
+				if path.Backend.Service == nil {
+					glog.Warningf("ingress %v/%v path %v does not reference a service", ing.Namespace, ing.Name, path.Path)
+					continue
+				}
+
+				name := upstreamName(ing.GetNamespace(), path.Backend.Service)
 
-				if _, ok := upstreams[name]; ok {
+				if ups, ok := upstreams[name]; ok {
+					n.setSessionAffinity(ups, ing, anns.SessionAffinity, affinityOwners)
 					continue
 				}
 
 				glog.V(3).Infof("creating upstream %v", name)
 				upstreams[name] = newUpstream(name)
-				if path.Backend.Service != nil {
-					if path.Backend.Service.Port.Number > 0 {
-						upstreams[name].Port = intstr.FromInt(int(path.Backend.Service.Port.Number))
-					}
-					if path.Backend.Service.Port.Name != "" {
-						upstreams[name].Port = intstr.FromString(path.Backend.Service.Port.Name)
-					}
+				if path.Backend.Service.Port.Number > 0 {
+					upstreams[name].Port = intstr.FromInt(int(path.Backend.Service.Port.Number))
+				}
+				if path.Backend.Service.Port.Name != "" {
+					upstreams[name].Port = intstr.FromString(path.Backend.Service.Port.Name)
 				}
 
 				if !upstreams[name].Secure {
 					upstreams[name].Secure = anns.SecureUpstream.Secure
 				}
 
+				if upstreams[name].BackendProtocol == "" {
+					upstreams[name].BackendProtocol = anns.BackendProtocol
+				}
+
 				if upstreams[name].SecureCACert.Secret == "" {
 					upstreams[name].SecureCACert = anns.SecureUpstream.CACert
 				}
@@ -258,16 +369,54 @@ func (n *NGINXController) createUpstreams(data []*networking.Ingress, ku *ingres
 					upstreams[name].ClientCACert = anns.SecureUpstream.ClientCACert
 				}
 
+				n.setSessionAffinity(upstreams[name], ing, anns.SessionAffinity, affinityOwners)
+
 				svcKey := fmt.Sprintf("%v/%v", ing.GetNamespace(), path.Backend.Service.Name)
 
 				s, err := n.listers.Service.GetByName(svcKey)
 				if err != nil {
 					glog.Warningf("error obtaining service: %v", err)
+					n.recordWarning(ing, reasonServiceNotFound, "service %v referenced by path %v not found", svcKey, path.Path)
+					continue
+				}
+
+				// the name of ExternalName services is resolved by NGINX. The port
+				// number of the Ingress is used when the service does not define it.
+				if s.Spec.Type == apiv1.ServiceTypeExternalName {
+					if svcPort := findServicePort(s, upstreams[name].Port); svcPort != nil {
+						upstreams[name].Port = intstr.FromInt(int(svcPort.Port))
+					} else if upstreams[name].Port.Type == intstr.String {
+						glog.Warningf("service %v does not contain port %v", svcKey, upstreams[name].Port.String())
+						n.recordWarning(ing, reasonPortNotFound, "service %v referenced by path %v does not contain port %v", svcKey, path.Path, upstreams[name].Port.String())
+						continue
+					}
+
+					if len(n.resolver) == 0 {
+						glog.Warningf("there are no nameservers to resolve the external name %v of service %v", s.Spec.ExternalName, svcKey)
+					}
+
+					if upstreams[name].SessionAffinity.Type != "" {
+						glog.Warningf("session affinity is not supported by service %v of type ExternalName", svcKey)
+					}
+
+					upstreams[name].Service = s
+					upstreams[name].ExternalName = s.Spec.ExternalName
 					continue
 				}
 
+				// named ports are replaced with the number of the service port, the
+				// target port of the endpoints is resolved using the port name
+				svcPort := findServicePort(s, upstreams[name].Port)
+				if svcPort == nil {
+					glog.Warningf("service %v does not contain port %v", svcKey, upstreams[name].Port.String())
+					n.recordWarning(ing, reasonPortNotFound, "service %v referenced by path %v does not contain port %v", svcKey, path.Path, upstreams[name].Port.String())
+					continue
+				}
+				upstreams[name].Port = intstr.FromInt(int(svcPort.Port))
+
 				upstreams[name].Service = s
 				upstreams[name].ClusterIP = s.Spec.ClusterIP
+				upstreams[name].Endpoints = getEndpoints(s, upstreams[name].Port, n.listers.Endpoint)
 			}
 		}
 	}
@@ -275,13 +424,24 @@ func (n *NGINXController) createUpstreams(data []*networking.Ingress, ku *ingres
 	return upstreams
 }
 
+// upstreamName returns the name of the upstream of a service referenced in
+// an Ingress, formatted as <namespace>-<name>-<port number or port name>
+func upstreamName(namespace string, service *networking.IngressServiceBackend) string {
+	port := fmt.Sprintf("%d", service.Port.Number)
+	if service.Port.Name != "" {
+		port = service.Port.Name
+	}
+	return fmt.Sprintf("%v-%v-%v", namespace, service.Name, port)
+}
+
 // createServers initializes a map that contains information about the list of
 // FDQN referenced by ingress rules and the common name field in the referenced
 // SSL certificates. Each server is configured with location / using a default
 // backend specified by the user or the one inside the ingress spec.
 func (n *NGINXController) createServers(data []*networking.Ingress,
 	upstreams map[string]*ingress.Backend,
-	ku *ingress.Backend) map[string]*ingress.Server {
+	ku *ingress.Backend,
+	cfg ngx_config.Configuration) map[string]*ingress.Server {
 
 	servers := make(map[string]*ingress.Server, len(data))
 
@@ -308,7 +468,8 @@ func (n *NGINXController) createServers(data []*networking.Ingress,
 				Rewrite: rewrite.Config{
 					Target: "/",
 				},
-				Proxy: proxy.DefaultProxyConfig,
+				Proxy:       proxy.DefaultProxyConfig,
+				SourceRange: getSourceRange(cfg, sourcerange.Config{}),
 			},
 		}}
 
@@ -319,7 +480,7 @@ func (n *NGINXController) createServers(data []*networking.Ingress,
 
 		if ing.Spec.DefaultBackend != nil && ing.Spec.DefaultBackend.Service != nil {
 			// replace default backend
-			defUpstream := fmt.Sprintf("%v-%v-%v", ing.GetNamespace(), ing.Spec.DefaultBackend.Service.Name, fmt.Sprintf("%d", ing.Spec.DefaultBackend.Service.Port.Number))
+			defUpstream := upstreamName(ing.GetNamespace(), ing.Spec.DefaultBackend.Service)
 			if backendUpstream, ok := upstreams[defUpstream]; ok {
 				un = backendUpstream.Name
 
@@ -334,6 +495,7 @@ func (n *NGINXController) createServers(data []*networking.Ingress,
 
 					// we need to use the ingress annotations
 					defLoc.ConfigurationSnippet = anns.ConfigurationSnippet
+					defLoc.SecurityHeaders = getSecurityHeaders(cfg, anns)
 				}
 			}
 		}
@@ -343,18 +505,30 @@ func (n *NGINXController) createServers(data []*networking.Ingress,
 			if host == "" {
 				host = defServerName
 			}
-			if _, ok := servers[host]; ok {
+			if server, ok := servers[host]; ok {
 				// server already configured
+				if anns.CertificateAuth.Secret == "" {
+					continue
+				}
+				if server.CertificateAuth.Secret == "" {
+					server.CertificateAuth = anns.CertificateAuth
+				} else if !(&server.CertificateAuth).Equal(&anns.CertificateAuth) {
+					glog.Warningf("ingress %v defines a client certificate authentication for host %v different from the one of an older ingress", ingressKey(ing), host)
+					n.recordWarning(ing, reasonConflict, "host %v already uses a different client certificate authentication, the auth-tls annotations are ignored", host)
+				}
 				continue
 			}
 
 			servers[host] = &ingress.Server{
-				Hostname: host,
+				Hostname:        host,
+				Namespace:       ing.Namespace,
+				CertificateAuth: anns.CertificateAuth,
 				Locations: []*ingress.Location{
 					{
-						Path:    rootLocation,
-						Backend: un,
-						Service: &apiv1.Service{},
+						Path:        rootLocation,
+						Backend:     un,
+						Service:     &apiv1.Service{},
+						SourceRange: getSourceRange(cfg, sourcerange.Config{}),
 					},
 				},
 			}
@@ -422,15 +596,35 @@ func (n *NGINXController) createServers(data []*networking.Ingress,
 }
 
 // getBackendServers returns a list of Upstream and Server to be used by the backend
-// An upstream can be used in multiple servers if the namespace, service name and port are the same
-func (n *NGINXController) getBackendServers(ingresses []*networking.Ingress) ([]*ingress.Backend, []*ingress.Server) {
+// An upstream can be used in multiple servers if the namespace, service name and port are the same.
+// When more than one Ingress defines the same host and path the location of the first one is
+// used, and the conflicts are returned. The configuration of the ConfigMap
+// provides the defaults of the annotations.
+func (n *NGINXController) getBackendServers(ingresses []*networking.Ingress, cfg ngx_config.Configuration) ([]*ingress.Backend, []*ingress.Server, []ingressConflict) {
+	ingresses = n.applyHostOwnership(ingresses, cfg)
+
 	ku := n.getKubernetesUpstream()
 	upstreams := n.createUpstreams(ingresses, ku)
-	servers := n.createServers(ingresses, upstreams, ku)
+	servers := n.createServers(ingresses, upstreams, ku, cfg)
 
-	for _, ing := range ingresses {
+	// the canary Ingresses are merged into the locations
+	// of the primary Ingresses, so they are processed last
+	primaries, canaries := n.splitCanaryIngresses(ingresses)
+	conflicts := []ingressConflict{}
+
+	for _, ing := range joinIngresses(primaries, canaries) {
 		anns := n.getIngressAnnotations(ing)
 
+		// the locations of canary Ingresses use the error pages of the primary
+		var errorPages ingress.ErrorPages
+		if !anns.Canary.Enabled {
+			errorPages = n.getErrorPages(ing, anns, upstreams, cfg)
+		}
+		sslRedirect, forceSSLRedirect := getSSLRedirect(cfg, anns)
+		securityHeaders := getSecurityHeaders(cfg, anns)
+		proxySetHeaders := n.getIngressProxySetHeaders(ing, anns)
+		sourceRange := getSourceRange(cfg, anns.SourceRange)
+
 		for _, rule := range ing.Spec.Rules {
 			host := rule.Host
 			if host == "" {
@@ -448,10 +642,11 @@ func (n *NGINXController) getBackendServers(ingresses []*networking.Ingress) ([]
 			}
 
 			for _, path := range rule.HTTP.Paths {
-				upsName := fmt.Sprintf("%v-%v-%v",
-					ing.GetNamespace(),
-					path.Backend.Service.Name,
-					fmt.Sprintf("%d", path.Backend.Service.Port.Number))
+				if path.Backend.Service == nil {
+					continue
+				}
+
+				upsName := upstreamName(ing.GetNamespace(), path.Backend.Service)
 
 				ups := upstreams[upsName]
 
@@ -461,54 +656,100 @@ func (n *NGINXController) getBackendServers(ingresses []*networking.Ingress) ([]
 					nginxPath = path.Path
 				}
 
+				pathType := networking.PathTypeImplementationSpecific
+				if path.PathType != nil {
+					pathType = *path.PathType
+				}
+				// the location-modifier annotation takes precedence over the path type
+				if anns.LocationModifier != "" {
+					pathType = networking.PathTypeImplementationSpecific
+				}
+				if pathType == networking.PathTypePrefix {
+					nginxPath = normalizePrefixPath(nginxPath)
+				}
+
+				if anns.Canary.Enabled {
+					n.addCanaryBackend(server, ing, anns, upstreams, ups, nginxPath, pathType)
+					continue
+				}
+
 				addLoc := true
 				for _, loc := range server.Locations {
-					if loc.Path == nginxPath {
+					if loc.Path == nginxPath && isExactLocation(loc) == (pathType == networking.PathTypeExact) {
 						addLoc = false
 
-						if ups.ClusterIP == "" {
+						if loc.Ingress != nil && ingressKey(loc.Ingress) != ingressKey(ing) {
+							conflicts = append(conflicts, newConflict(server.Hostname, nginxPath, loc.Ingress, ing))
+							break
+						}
+
+						if !hasUpstreamServers(ups) {
 							break
 						}
 
 						glog.V(3).Infof("replacing ingress rule %v/%v location %v upstream %v (%v)", ing.Namespace, ing.Name, loc.Path, ups.Name, loc.Backend)
 						loc.Backend = ups.Name
+						loc.PathType = pathType
 						loc.Port = ups.Port
 						loc.Service = ups.Service
 						loc.Ingress = ing
 						loc.ConfigurationSnippet = anns.ConfigurationSnippet
 						loc.Rewrite = anns.Rewrite
+						loc.Redirect = anns.Redirect
+						loc.SSLRedirect = sslRedirect
+						loc.ForceSSLRedirect = forceSSLRedirect
 						loc.Proxy = anns.Proxy
 						loc.XForwardedPrefix = anns.XForwardedPrefix
 						loc.AuthType = anns.AuthType
 						loc.AuthzType = anns.AuthzType
+						loc.ExternalAuth = anns.ExternalAuth
+						loc.CertificateAuth = anns.CertificateAuth
 						loc.UpstreamURI = anns.UpstreamURI
 						loc.LocationModifier = anns.LocationModifier
 						loc.Connection = anns.Connection
+						loc.RateLimit = anns.RateLimit
+						loc.SourceRange = sourceRange
+						loc.ErrorPages = errorPages
+						loc.Compression = anns.Compression
+						loc.ProxySetHeaders = proxySetHeaders
+						loc.SecurityHeaders = securityHeaders
 						break
 					}
 				}
 				// is a new location
 				if addLoc {
 					glog.V(3).Infof("adding location %v in ingress rule %v/%v upstream %v", nginxPath, ing.Namespace, ing.Name, ups.Name)
-					if ups.ClusterIP == "" {
+					if !hasUpstreamServers(ups) {
 						continue
 					}
 
 					loc := &ingress.Location{
 						Path:                 nginxPath,
+						PathType:             pathType,
 						Backend:              ups.Name,
 						Service:              ups.Service,
 						Port:                 ups.Port,
 						Ingress:              ing,
 						ConfigurationSnippet: anns.ConfigurationSnippet,
 						Rewrite:              anns.Rewrite,
+						Redirect:             anns.Redirect,
+						SSLRedirect:          sslRedirect,
+						ForceSSLRedirect:     forceSSLRedirect,
 						Proxy:                anns.Proxy,
 						XForwardedPrefix:     anns.XForwardedPrefix,
 						AuthType:             anns.AuthType,
 						AuthzType:            anns.AuthzType,
+						ExternalAuth:         anns.ExternalAuth,
+						CertificateAuth:      anns.CertificateAuth,
 						LocationModifier:     anns.LocationModifier,
 						UpstreamURI:          anns.UpstreamURI,
 						Connection:           anns.Connection,
+						RateLimit:            anns.RateLimit,
+						SourceRange:          sourceRange,
+						ErrorPages:           errorPages,
+						Compression:          anns.Compression,
+						ProxySetHeaders:      proxySetHeaders,
+						SecurityHeaders:      securityHeaders,
 					}
 
 					server.Locations = append(server.Locations, loc)
@@ -521,14 +762,19 @@ func (n *NGINXController) getBackendServers(ingresses []*networking.Ingress) ([]
 
 	// create the list of upstreams and skip those without endpoints
 	for _, upstream := range upstreams {
-		if upstream.ClusterIP == "" {
+		if !hasUpstreamServers(upstream) {
 			continue
 		}
 		aUpstreams = append(aUpstreams, upstream)
 	}
 
+	sort.SliceStable(aUpstreams, func(i, j int) bool {
+		return aUpstreams[i].Name < aUpstreams[j].Name
+	})
+
 	aServers := make([]*ingress.Server, 0, len(servers))
 	for _, value := range servers {
+		addPrefixExactLocations(value)
 		sort.SliceStable(value.Locations, func(i, j int) bool {
 			return value.Locations[i].Path > value.Locations[j].Path
 		})
@@ -539,7 +785,20 @@ func (n *NGINXController) getBackendServers(ingresses []*networking.Ingress) ([]
 		return aServers[i].Hostname < aServers[j].Hostname
 	})
 
-	return aUpstreams, aServers
+	return aUpstreams, aServers, conflicts
+}
+
+// getSourceRange returns the source ranges of the locations of an Ingress,
+// using the ones of the configuration when the Ingress does not define them.
+// They are part of the locations, so a change of the configuration updates them.
+func getSourceRange(cfg ngx_config.Configuration, sr sourcerange.Config) sourcerange.Config {
+	if len(sr.Allowlist) == 0 {
+		sr.Allowlist = cfg.AllowlistSourceRange
+	}
+	if len(sr.Denylist) == 0 {
+		sr.Denylist = cfg.DenylistSourceRange
+	}
+	return sr
 }
 
 // GetAuthCertificate is used by the auth-tls annotations to get a cert from a secret
@@ -578,6 +837,10 @@ func (n NGINXController) GetService(name string) (*apiv1.Service, error) {
 
 func (n *NGINXController) extractAnnotations(ing *networking.Ingress) {
 	glog.V(3).Infof("updating annotations information for ingress %v/%v", ing.Namespace, ing.Name)
+	if err := n.annotations.Validate(ing); err != nil {
+		n.recordWarning(ing, reasonInvalidAnnotation, "%v", err)
+	}
+
 	anns := n.annotations.Extract(ing)
 	err := n.listers.IngressAnnotation.Update(anns)
 	if err != nil {
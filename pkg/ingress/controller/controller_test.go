@@ -0,0 +1,145 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/class"
+)
+
+// syncReloads runs a sync of the controller and returns true if NGINX is
+// reloaded. The binary of the controller rejects every configuration, so
+// a reload records a ReloadFailed event in the Ingress.
+func syncReloads(n *NGINXController) bool {
+	recorder := record.NewFakeRecorder(10)
+	n.recorder = recorder
+
+	// the result of the reload is checked with the events
+	_ = n.syncIngress(nil)
+	close(recorder.Events)
+
+	for event := range recorder.Events {
+		if strings.HasPrefix(event, "Warning ReloadFailed") {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSyncConfigMapChanges(t *testing.T) {
+	ic := class.IngressClass
+	class.IngressClass = class.DefaultClass
+	defer func() {
+		class.IngressClass = ic
+	}()
+
+	headers := &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "headers", Namespace: metav1.NamespaceDefault},
+		Data:       map[string]string{"X-Foo": "bar"},
+	}
+	updateHeaders := func(n *NGINXController) {
+		updated := headers.DeepCopy()
+		updated.Data["X-Foo"] = "baz"
+		if err := n.listers.ConfigMap.Update(updated); err != nil {
+			t.Fatalf("unexpected error updating configmap: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		configuration map[string]string
+		update        func(n *NGINXController)
+	}{
+		{"add-headers configmap", map[string]string{"add-headers": "default/headers"}, updateHeaders},
+		{"security headers", map[string]string{"add-headers": "default/headers"}, func(n *NGINXController) {
+			n.SetConfig(&apiv1.ConfigMap{Data: map[string]string{
+				"add-headers":  "default/headers",
+				"hsts-max-age": "31536000",
+			}})
+		}},
+		{"proxy-set-headers configmap", map[string]string{"proxy-set-headers": "default/headers"}, updateHeaders},
+		{"proxy-hide-headers", map[string]string{}, func(n *NGINXController) {
+			n.SetConfig(&apiv1.ConfigMap{Data: map[string]string{"proxy-hide-headers": "X-Powered-By"}})
+		}},
+		{"compression", map[string]string{}, func(n *NGINXController) {
+			n.SetConfig(&apiv1.ConfigMap{Data: map[string]string{
+				"use-gzip":      "false",
+				"gzip-types":    "text/html",
+				"enable-brotli": "true",
+				"brotli-level":  "6",
+				"brotli-types":  "text/html",
+			}})
+		}},
+	}
+
+	for _, tc := range testCases {
+		n := buildControllerForChecker(t, "/bin/false")
+		n.syncRateLimiter = flowcontrol.NewFakeAlwaysRateLimiter()
+		addServiceForChecker(t, n, "foo")
+		if err := n.listers.ConfigMap.Add(headers.DeepCopy()); err != nil {
+			t.Fatalf("unexpected error adding configmap: %v", err)
+		}
+		n.SetConfig(&apiv1.ConfigMap{Data: tc.configuration})
+
+		ing := buildIngressForChecker(class.DefaultClass, nil)
+		if err := n.listers.Ingress.Add(ing); err != nil {
+			t.Fatalf("unexpected error adding ingress: %v", err)
+		}
+		n.extractAnnotations(ing)
+
+		// the running configuration is the one of the Ingress
+		pcfg, _ := n.buildConfiguration(n.getValidIngresses(), n.readConfig())
+		n.runningConfig = &pcfg
+		if syncReloads(n) {
+			t.Fatalf("%v: expected no reload without changes", tc.name)
+		}
+
+		// the configmap event handlers force the reload
+		tc.update(n)
+		n.SetForceReload(true)
+		if !syncReloads(n) {
+			t.Errorf("%v: expected a reload after the change of the configmap", tc.name)
+		}
+	}
+}
+
+func TestSyncSourceRangeChanges(t *testing.T) {
+	ic := class.IngressClass
+	class.IngressClass = class.DefaultClass
+	defer func() {
+		class.IngressClass = ic
+	}()
+
+	n := buildControllerForChecker(t, "/bin/false")
+	n.syncRateLimiter = flowcontrol.NewFakeAlwaysRateLimiter()
+	addServiceForChecker(t, n, "foo")
+	n.SetConfig(&apiv1.ConfigMap{Data: map[string]string{"allowlist-source-range": "10.0.0.0/8"}})
+
+	ing := buildIngressForChecker(class.DefaultClass, nil)
+	if err := n.listers.Ingress.Add(ing); err != nil {
+		t.Fatalf("unexpected error adding ingress: %v", err)
+	}
+	n.extractAnnotations(ing)
+
+	pcfg, _ := n.buildConfiguration(n.getValidIngresses(), n.readConfig())
+	n.runningConfig = &pcfg
+	if syncReloads(n) {
+		t.Fatalf("expected no reload without changes")
+	}
+
+	// the source ranges of the configuration are part of the locations,
+	// so the change is detected without forcing the reload
+	n.SetConfig(&apiv1.ConfigMap{Data: map[string]string{"allowlist-source-range": "192.168.0.0/16"}})
+	n.SetForceReload(false)
+	if !syncReloads(n) {
+		t.Errorf("expected a reload after the change of the allowlist")
+	}
+}
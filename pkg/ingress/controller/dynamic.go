@@ -0,0 +1,92 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stolostron/management-ingress/pkg/ingress"
+)
+
+const (
+	// backendsConfigurationPath is the location of the status server
+	// that receives the endpoints used by the Lua balancer
+	backendsConfigurationPath = "/configuration/backends"
+
+	dynamicConfigurationInterval = 500 * time.Millisecond
+	dynamicConfigurationTimeout  = 10 * time.Second
+)
+
+// dynamicBackend is the representation of a Backend sent to the Lua balancer
+type dynamicBackend struct {
+	Name      string             `json:"name"`
+	Endpoints []ingress.Endpoint `json:"endpoints"`
+}
+
+// backendsConfigurationURL returns the URL of the status server location
+// that configures the endpoints of the Lua balancer
+func (n *NGINXController) backendsConfigurationURL() string {
+	return fmt.Sprintf("http://127.0.0.1:%v%v", n.cfg.ListenPorts.Status, backendsConfigurationPath)
+}
+
+// configureBackends sends the endpoints of the backends that support dynamic
+// endpoints to the Lua balancer running in NGINX. The backends that are not
+// included keep using the servers rendered in the upstream.
+func configureBackends(url string, backends []*ingress.Backend) error {
+	dynamic := []dynamicBackend{}
+	for _, backend := range backends {
+		if !backend.DynamicEndpoints() {
+			continue
+		}
+		dynamic = append(dynamic, dynamicBackend{
+			Name:      backend.Name,
+			Endpoints: upstreamEndpoints(backend),
+		})
+	}
+
+	buf, err := json.Marshal(dynamic)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	// #nosec
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("unexpected response configuring the backend endpoints: %v", resp.Status)
+	}
+
+	return nil
+}
+
+// upstreamEndpoints returns the ready endpoints of a backend. Like the upstreams
+// in the NGINX template, the service ClusterIP is used when there are no ready
+// endpoints.
+func upstreamEndpoints(backend *ingress.Backend) []ingress.Endpoint {
+	endpoints := []ingress.Endpoint{}
+	for _, ep := range backend.Endpoints {
+		if ep.Ready {
+			endpoints = append(endpoints, ep)
+		}
+	}
+
+	if len(endpoints) == 0 && backend.ClusterIP != "" {
+		endpoints = append(endpoints, ingress.Endpoint{
+			Address: backend.ClusterIP,
+			Port:    backend.Port.String(),
+			Ready:   true,
+		})
+	}
+
+	return endpoints
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/stolostron/management-ingress/pkg/ingress"
+)
+
+func TestConfigureBackends(t *testing.T) {
+	backends := []*ingress.Backend{
+		{
+			Name: "default-web-80",
+			Endpoints: []ingress.Endpoint{
+				{Address: "10.1.0.1", Port: "8080", Ready: true},
+				{Address: "10.1.0.2", Port: "8080", Ready: false},
+			},
+		},
+		{
+			Name:      "default-api-443",
+			ClusterIP: "10.0.0.10",
+			Port:      intstr.FromInt(443),
+		},
+		{
+			Name:           "default-hash-80",
+			UpstreamHashBy: "$request_uri",
+			Endpoints:      []ingress.Endpoint{{Address: "10.1.0.3", Port: "80", Ready: true}},
+		},
+	}
+
+	expected := []dynamicBackend{
+		{Name: "default-web-80", Endpoints: []ingress.Endpoint{{Address: "10.1.0.1", Port: "8080", Ready: true}}},
+		{Name: "default-api-443", Endpoints: []ingress.Endpoint{{Address: "10.0.0.10", Port: "443", Ready: true}}},
+	}
+
+	var received []dynamicBackend
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != backendsConfigurationPath {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	if err := configureBackends(server.URL+backendsConfigurationPath, backends); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(received, expected) {
+		t.Errorf("expected %+v but got %+v", expected, received)
+	}
+
+	if err := configureBackends(server.URL+"/invalid", backends); err == nil {
+		t.Errorf("expected an error with an unexpected response")
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/golang/glog"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/stolostron/management-ingress/pkg/ingress"
+	"github.com/stolostron/management-ingress/pkg/ingress/store"
+)
+
+// getEndpoints returns the pod addresses behind the service port referenced
+// in an Ingress rule. Addresses that did not pass the readiness checks are
+// also returned, with Ready set to false.
+func getEndpoints(s *apiv1.Service, port intstr.IntOrString, epLister store.EndpointLister) []ingress.Endpoint {
+	upsServers := []ingress.Endpoint{}
+
+	if s == nil {
+		return upsServers
+	}
+
+	svcPort := findServicePort(s, port)
+	if svcPort == nil {
+		glog.Warningf("service %v/%v does not contain port %v", s.Namespace, s.Name, port.String())
+		return upsServers
+	}
+
+	ep, err := epLister.GetServiceEndpoints(s)
+	if err != nil {
+		glog.V(3).Infof("error obtaining endpoints for service %v/%v: %v", s.Namespace, s.Name, err)
+		return upsServers
+	}
+
+	// avoid duplicated upstream servers when the service
+	// contains multiple port definitions sharing the same
+	// targetport.
+	processed := make(map[string]bool)
+
+	addEndpoint := func(addr apiv1.EndpointAddress, epPort apiv1.EndpointPort, ready bool) {
+		key := fmt.Sprintf("%v:%v", addr.IP, epPort.Port)
+		if processed[key] {
+			return
+		}
+		processed[key] = true
+
+		upsServers = append(upsServers, ingress.Endpoint{
+			Address: addr.IP,
+			Port:    fmt.Sprintf("%v", epPort.Port),
+			Ready:   ready,
+		})
+	}
+
+	for _, ss := range ep.Subsets {
+		for _, epPort := range ss.Ports {
+			if epPort.Name != svcPort.Name {
+				continue
+			}
+
+			if epPort.Protocol != "" && epPort.Protocol != apiv1.ProtocolTCP {
+				continue
+			}
+
+			for _, addr := range ss.Addresses {
+				addEndpoint(addr, epPort, true)
+			}
+
+			for _, addr := range ss.NotReadyAddresses {
+				addEndpoint(addr, epPort, false)
+			}
+		}
+	}
+
+	sort.SliceStable(upsServers, func(i, j int) bool {
+		if upsServers[i].Address != upsServers[j].Address {
+			return upsServers[i].Address < upsServers[j].Address
+		}
+		return upsServers[i].Port < upsServers[j].Port
+	})
+
+	glog.V(3).Infof("endpoints found for service %v/%v port %v: %v", s.Namespace, s.Name, port.String(), upsServers)
+	return upsServers
+}
+
+// findServicePort returns the port definition of the service referenced
+// by number or by name.
+func findServicePort(s *apiv1.Service, port intstr.IntOrString) *apiv1.ServicePort {
+	for i, sp := range s.Spec.Ports {
+		switch port.Type {
+		case intstr.Int:
+			if sp.Port == port.IntVal {
+				return &s.Spec.Ports[i]
+			}
+		case intstr.String:
+			if sp.Name == port.StrVal {
+				return &s.Spec.Ports[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+// hasUpstreamServers returns true if the backend contains at least one
+// ready endpoint, a ClusterIP that can be used as fallback or an external name.
+func hasUpstreamServers(b *ingress.Backend) bool {
+	if b.ExternalName != "" {
+		return true
+	}
+
+	for _, ep := range b.Endpoints {
+		if ep.Ready {
+			return true
+		}
+	}
+
+	return b.ClusterIP != "" && b.ClusterIP != apiv1.ClusterIPNone
+}
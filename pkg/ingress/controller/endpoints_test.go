@@ -0,0 +1,249 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	cache_client "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/stolostron/management-ingress/pkg/ingress"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/class"
+	"github.com/stolostron/management-ingress/pkg/ingress/store"
+)
+
+func buildEndpointListerForTest(eps ...*apiv1.Endpoints) store.EndpointLister {
+	epLister := store.EndpointLister{}
+	epLister.Store = cache_client.NewStore(cache_client.MetaNamespaceKeyFunc)
+	for _, ep := range eps {
+		epLister.Add(ep)
+	}
+	return epLister
+}
+
+func TestGetEndpoints(t *testing.T) {
+	svc := &apiv1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: metav1.NamespaceDefault,
+		},
+		Spec: apiv1.ServiceSpec{
+			ClusterIP: "10.0.0.1",
+			Ports: []apiv1.ServicePort{
+				{Name: "http", Port: 80, TargetPort: intstr.FromInt(8080)},
+				{Name: "https", Port: 443, TargetPort: intstr.FromInt(8443)},
+			},
+		},
+	}
+
+	ep := &apiv1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: metav1.NamespaceDefault,
+		},
+		Subsets: []apiv1.EndpointSubset{
+			{
+				Addresses: []apiv1.EndpointAddress{
+					{IP: "10.1.0.2"},
+					{IP: "10.1.0.1"},
+				},
+				NotReadyAddresses: []apiv1.EndpointAddress{
+					{IP: "10.1.0.3"},
+				},
+				Ports: []apiv1.EndpointPort{
+					{Name: "http", Port: 8080, Protocol: apiv1.ProtocolTCP},
+					{Name: "https", Port: 8443, Protocol: apiv1.ProtocolTCP},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		svc      *apiv1.Service
+		port     intstr.IntOrString
+		lister   store.EndpointLister
+		expected []ingress.Endpoint
+	}{
+		{"nil service", nil, intstr.FromInt(80), buildEndpointListerForTest(ep), []ingress.Endpoint{}},
+		{"unknown service port", svc, intstr.FromInt(8000), buildEndpointListerForTest(ep), []ingress.Endpoint{}},
+		{"no endpoints", svc, intstr.FromInt(80), buildEndpointListerForTest(), []ingress.Endpoint{}},
+		{"port by number", svc, intstr.FromInt(80), buildEndpointListerForTest(ep), []ingress.Endpoint{
+			{Address: "10.1.0.1", Port: "8080", Ready: true},
+			{Address: "10.1.0.2", Port: "8080", Ready: true},
+			{Address: "10.1.0.3", Port: "8080", Ready: false},
+		}},
+		{"port by name", svc, intstr.FromString("https"), buildEndpointListerForTest(ep), []ingress.Endpoint{
+			{Address: "10.1.0.1", Port: "8443", Ready: true},
+			{Address: "10.1.0.2", Port: "8443", Ready: true},
+			{Address: "10.1.0.3", Port: "8443", Ready: false},
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			eps := getEndpoints(tc.svc, tc.port, tc.lister)
+			if !reflect.DeepEqual(eps, tc.expected) {
+				t.Errorf("expected %v but returned %v", tc.expected, eps)
+			}
+		})
+	}
+}
+
+func TestHasUpstreamServers(t *testing.T) {
+	testCases := []struct {
+		name     string
+		backend  *ingress.Backend
+		expected bool
+	}{
+		{"empty backend", &ingress.Backend{}, false},
+		{"cluster ip", &ingress.Backend{ClusterIP: "10.0.0.1"}, true},
+		{"headless service", &ingress.Backend{ClusterIP: apiv1.ClusterIPNone}, false},
+		{"not ready endpoints", &ingress.Backend{ClusterIP: apiv1.ClusterIPNone, Endpoints: []ingress.Endpoint{{Address: "10.1.0.1", Port: "80"}}}, false},
+		{"ready endpoints", &ingress.Backend{ClusterIP: apiv1.ClusterIPNone, Endpoints: []ingress.Endpoint{{Address: "10.1.0.1", Port: "80", Ready: true}}}, true},
+	}
+
+	for _, tc := range testCases {
+		if r := hasUpstreamServers(tc.backend); r != tc.expected {
+			t.Errorf("%v: expected %v but returned %v", tc.name, tc.expected, r)
+		}
+	}
+}
+
+func TestCreateUpstreamsNamedPort(t *testing.T) {
+	ic := class.IngressClass
+	class.IngressClass = class.DefaultClass
+	defer func() {
+		class.IngressClass = ic
+	}()
+
+	n := buildControllerForChecker(t, "/bin/true")
+	recorder := record.NewFakeRecorder(10)
+	n.recorder = recorder
+
+	err := n.listers.Service.Add(&apiv1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: metav1.NamespaceDefault},
+		Spec: apiv1.ServiceSpec{
+			ClusterIP: "10.0.0.1",
+			Ports: []apiv1.ServicePort{
+				{Name: "http", Port: 80, TargetPort: intstr.FromString("web")},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error adding service: %v", err)
+	}
+	err = n.listers.Endpoint.Add(&apiv1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: metav1.NamespaceDefault},
+		Subsets: []apiv1.EndpointSubset{{
+			Addresses: []apiv1.EndpointAddress{{IP: "10.1.0.1"}},
+			Ports:     []apiv1.EndpointPort{{Name: "http", Port: 8080}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error adding endpoints: %v", err)
+	}
+
+	ing := buildIngressForChecker(class.DefaultClass, nil)
+	ing.Spec.Rules[0].HTTP.Paths = append(ing.Spec.Rules[0].HTTP.Paths, networking.HTTPIngressPath{
+		Path: "/bar",
+		Backend: networking.IngressBackend{
+			Service: &networking.IngressServiceBackend{
+				Name: "foo",
+				Port: networking.ServiceBackendPort{Name: "missing"},
+			},
+		},
+	})
+	ing.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Port = networking.ServiceBackendPort{Name: "http"}
+	n.extractAnnotations(ing)
+
+	upstreams := n.createUpstreams([]*networking.Ingress{ing}, &ingress.Backend{})
+
+	ups := upstreams["default-foo-http"]
+	if ups == nil {
+		t.Fatalf("expected an upstream for the named port")
+	}
+	if ups.Port != intstr.FromInt(80) {
+		t.Errorf("expected the service port 80 but got %v", ups.Port.String())
+	}
+	expected := []ingress.Endpoint{{Address: "10.1.0.1", Port: "8080", Ready: true}}
+	if !reflect.DeepEqual(ups.Endpoints, expected) {
+		t.Errorf("expected endpoints %v but got %v", expected, ups.Endpoints)
+	}
+
+	if missing := upstreams["default-foo-missing"]; missing == nil || hasUpstreamServers(missing) {
+		t.Errorf("expected an upstream without servers for the missing port but got %v", missing)
+	}
+
+	close(recorder.Events)
+	event := <-recorder.Events
+	if !strings.HasPrefix(event, "Warning PortNotFound service default/foo referenced by path /bar does not contain port missing") {
+		t.Errorf("unexpected event %q", event)
+	}
+}
+
+func TestCreateUpstreamsExternalName(t *testing.T) {
+	ic := class.IngressClass
+	class.IngressClass = class.DefaultClass
+	defer func() {
+		class.IngressClass = ic
+	}()
+
+	n := buildControllerForChecker(t, "/bin/true")
+
+	err := n.listers.Service.Add(&apiv1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: metav1.NamespaceDefault},
+		Spec: apiv1.ServiceSpec{
+			Type:         apiv1.ServiceTypeExternalName,
+			ExternalName: "foo.example.com",
+			Ports: []apiv1.ServicePort{
+				{Name: "https", Port: 443},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error adding service: %v", err)
+	}
+
+	ing := buildIngressForChecker(class.DefaultClass, nil)
+	ing.Spec.Rules[0].HTTP.Paths = append(ing.Spec.Rules[0].HTTP.Paths, networking.HTTPIngressPath{
+		Path: "/bar",
+		Backend: networking.IngressBackend{
+			Service: &networking.IngressServiceBackend{
+				Name: "foo",
+				Port: networking.ServiceBackendPort{Name: "https"},
+			},
+		},
+	})
+	n.extractAnnotations(ing)
+
+	upstreams := n.createUpstreams([]*networking.Ingress{ing}, &ingress.Backend{})
+
+	testCases := map[string]string{
+		"default-foo-80":    "80",
+		"default-foo-https": "443",
+	}
+	for name, port := range testCases {
+		ups := upstreams[name]
+		if ups == nil {
+			t.Fatalf("expected the upstream %v", name)
+		}
+		if ups.ExternalName != "foo.example.com" {
+			t.Errorf("%v: expected the external name foo.example.com but got %q", name, ups.ExternalName)
+		}
+		if ups.Port.String() != port {
+			t.Errorf("%v: expected the port %v but got %v", name, port, ups.Port.String())
+		}
+		if !hasUpstreamServers(ups) || ups.DynamicEndpoints() {
+			t.Errorf("%v: expected a backend with servers and without dynamic endpoints", name)
+		}
+	}
+}
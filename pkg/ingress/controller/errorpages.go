@@ -0,0 +1,175 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/stolostron/management-ingress/pkg/ingress"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations"
+	ngx_config "github.com/stolostron/management-ingress/pkg/ingress/controller/config"
+)
+
+// defaultErrorPageKey is the key of the ConfigMap with the error page used
+// for the status codes without a <code>.html key
+const defaultErrorPageKey = "default.html"
+
+// getErrorPagesService returns the service referenced by the default-backend
+// annotation of an Ingress. The pages are requested to the first port of the
+// service, ExternalName services are not supported.
+func (n *NGINXController) getErrorPagesService(ing *networking.Ingress, anns *annotations.Ingress) *apiv1.Service {
+	if anns.DefaultBackend.Service == "" {
+		return nil
+	}
+
+	svcKey := fmt.Sprintf("%v/%v", ing.GetNamespace(), anns.DefaultBackend.Service)
+	s, err := n.listers.Service.GetByName(svcKey)
+	if err != nil {
+		glog.Warningf("error obtaining service: %v", err)
+		n.recordWarning(ing, reasonServiceNotFound, "service %v referenced by the default-backend annotation not found", svcKey)
+		return nil
+	}
+
+	if s.Spec.Type == apiv1.ServiceTypeExternalName || len(s.Spec.Ports) == 0 {
+		glog.Warningf("service %v of the default-backend annotation of ingress %v must be of type ClusterIP with at least one port", svcKey, ingressKey(ing))
+		n.recordWarning(ing, reasonPortNotFound, "service %v referenced by the default-backend annotation does not contain ports", svcKey)
+		return nil
+	}
+
+	return s
+}
+
+// errorPagesUpstreamName returns the name of the upstream of a service
+// that returns error pages
+func errorPagesUpstreamName(s *apiv1.Service) string {
+	return upstreamName(s.Namespace, &networking.IngressServiceBackend{
+		Name: s.Name,
+		Port: networking.ServiceBackendPort{Number: s.Spec.Ports[0].Port},
+	})
+}
+
+// createErrorPagesUpstream adds the upstream of the service referenced by the
+// default-backend annotation of an Ingress
+func (n *NGINXController) createErrorPagesUpstream(upstreams map[string]*ingress.Backend, ing *networking.Ingress, anns *annotations.Ingress) {
+	s := n.getErrorPagesService(ing, anns)
+	if s == nil {
+		return
+	}
+
+	name := errorPagesUpstreamName(s)
+	if _, ok := upstreams[name]; ok {
+		return
+	}
+
+	glog.V(3).Infof("creating upstream %v for the error pages of ingress %v", name, ingressKey(ing))
+	upstreams[name] = newUpstream(name)
+	upstreams[name].Port = intstr.FromInt(int(s.Spec.Ports[0].Port))
+	upstreams[name].Service = s
+	upstreams[name].ClusterIP = s.Spec.ClusterIP
+	upstreams[name].Endpoints = getEndpoints(s, upstreams[name].Port, n.listers.Endpoint)
+}
+
+// getErrorPages returns the error pages of the locations of an Ingress. The
+// status codes of the custom-http-errors annotation, or the ones of the
+// configuration when the Ingress does not define them, are replaced with the
+// pages of the service or the ConfigMap of the default-backend annotation.
+func (n *NGINXController) getErrorPages(ing *networking.Ingress, anns *annotations.Ingress, upstreams map[string]*ingress.Backend, cfg ngx_config.Configuration) ingress.ErrorPages {
+	if anns.DefaultBackend.Service == "" && anns.DefaultBackend.ConfigMap == "" {
+		if len(anns.CustomHTTPErrors) > 0 {
+			glog.Warningf("ingress %v defines custom http errors without a default backend", ingressKey(ing))
+			n.recordWarning(ing, reasonInvalidAnnotation, "the custom-http-errors annotation requires the default-backend annotation")
+		}
+		return ingress.ErrorPages{}
+	}
+
+	codes := anns.CustomHTTPErrors
+	if len(codes) == 0 {
+		codes = cfg.CustomHTTPErrors
+	}
+	if len(codes) == 0 {
+		glog.Warningf("ingress %v defines a default backend without status codes for the error pages", ingressKey(ing))
+		return ingress.ErrorPages{}
+	}
+
+	if anns.DefaultBackend.Service != "" {
+		// the problems of the service are reported creating the upstream
+		svcKey := fmt.Sprintf("%v/%v", ing.GetNamespace(), anns.DefaultBackend.Service)
+		s, err := n.listers.Service.GetByName(svcKey)
+		if err != nil || s.Spec.Type == apiv1.ServiceTypeExternalName || len(s.Spec.Ports) == 0 {
+			return ingress.ErrorPages{}
+		}
+
+		ups := upstreams[errorPagesUpstreamName(s)]
+		if ups == nil || !hasUpstreamServers(ups) {
+			glog.Warningf("service %v of the default-backend annotation of ingress %v does not have active endpoints", svcKey, ingressKey(ing))
+			return ingress.ErrorPages{}
+		}
+
+		return ingress.ErrorPages{Codes: codes, Backend: ups.Name}
+	}
+
+	cmKey := fmt.Sprintf("%v/%v", ing.GetNamespace(), anns.DefaultBackend.ConfigMap)
+	cm, err := n.listers.ConfigMap.GetByName(cmKey)
+	if err != nil {
+		glog.Warningf("error obtaining configmap: %v", err)
+		n.recordWarning(ing, reasonConfigMapNotFound, "configmap %v referenced by the default-backend annotation not found", cmKey)
+		return ingress.ErrorPages{}
+	}
+
+	errorPages := ingress.ErrorPages{
+		ConfigMap: cmKey,
+		Pages:     map[int]string{},
+	}
+	for _, code := range codes {
+		page, ok := cm.Data[fmt.Sprintf("%v.html", code)]
+		if !ok {
+			page, ok = cm.Data[defaultErrorPageKey]
+		}
+		if !ok {
+			glog.Warningf("configmap %v does not contain an error page for status code %v", cmKey, code)
+			continue
+		}
+
+		errorPages.Codes = append(errorPages.Codes, code)
+		errorPages.Pages[code] = page
+	}
+	if len(errorPages.Codes) == 0 {
+		return ingress.ErrorPages{}
+	}
+
+	return errorPages
+}
+
+// hasErrorPages checks if a location of the servers uses error pages
+func hasErrorPages(servers []*ingress.Server) bool {
+	for _, server := range servers {
+		for _, location := range server.Locations {
+			if len(location.ErrorPages.Codes) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isErrorPagesConfigMap checks if a ConfigMap contains the error pages
+// of the default-backend annotation of an Ingress
+func (n *NGINXController) isErrorPagesConfigMap(cm *apiv1.ConfigMap) bool {
+	for _, item := range n.listers.IngressAnnotation.List() {
+		anns, ok := item.(*annotations.Ingress)
+		if !ok {
+			continue
+		}
+		if anns.Namespace == cm.Namespace && anns.DefaultBackend.ConfigMap == cm.Name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/stolostron/management-ingress/pkg/ingress"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/class"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+)
+
+func TestErrorPages(t *testing.T) {
+	ic := class.IngressClass
+	class.IngressClass = class.DefaultClass
+	defer func() {
+		class.IngressClass = ic
+	}()
+
+	customErrors := parser.GetAnnotationWithPrefix("custom-http-errors")
+	defaultBackend := parser.GetAnnotationWithPrefix("default-backend")
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		configmap   map[string]string
+		expected    ingress.ErrorPages
+		event       bool
+	}{
+		{"without annotations", nil, nil, ingress.ErrorPages{}, false},
+		{"service", map[string]string{customErrors: "404,503", defaultBackend: "errors"}, nil, ingress.ErrorPages{
+			Codes:   []int{404, 503},
+			Backend: "default-errors-80",
+		}, false},
+		{"codes of the configuration", map[string]string{defaultBackend: "errors"}, map[string]string{"custom-http-errors": "500"}, ingress.ErrorPages{
+			Codes:   []int{500},
+			Backend: "default-errors-80",
+		}, false},
+		{"configmap", map[string]string{customErrors: "404,500,503", defaultBackend: "configmap/pages"}, nil, ingress.ErrorPages{
+			Codes:     []int{404, 500, 503},
+			ConfigMap: "default/pages",
+			Pages:     map[int]string{404: "not found", 500: "error", 503: "error"},
+		}, false},
+		{"missing service", map[string]string{customErrors: "404", defaultBackend: "missing"}, nil, ingress.ErrorPages{}, true},
+		{"missing configmap", map[string]string{customErrors: "404", defaultBackend: "configmap/missing"}, nil, ingress.ErrorPages{}, true},
+		{"without default backend", map[string]string{customErrors: "404"}, nil, ingress.ErrorPages{}, true},
+	}
+
+	for _, tc := range testCases {
+		n := buildControllerForChecker(t, "/bin/true")
+		recorder := record.NewFakeRecorder(10)
+		n.recorder = recorder
+		n.configmap = &apiv1.ConfigMap{Data: tc.configmap}
+		addServiceForChecker(t, n, "foo")
+		addServiceForChecker(t, n, "errors")
+		err := n.listers.ConfigMap.Add(&apiv1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "pages", Namespace: metav1.NamespaceDefault},
+			Data:       map[string]string{"404.html": "not found", "default.html": "error"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error adding configmap: %v", err)
+		}
+
+		ing := buildIngressForChecker(class.DefaultClass, tc.annotations)
+		n.extractAnnotations(ing)
+
+		upstreams, servers, _ := n.getBackendServers([]*networking.Ingress{ing}, n.readConfig())
+		if tc.expected.Backend != "" {
+			found := false
+			for _, ups := range upstreams {
+				found = found || ups.Name == tc.expected.Backend
+			}
+			if !found {
+				t.Errorf("%v: expected the upstream %v", tc.name, tc.expected.Backend)
+			}
+		}
+
+		var location *ingress.Location
+		for _, loc := range servers[0].Locations {
+			if loc.Path == "/foo" {
+				location = loc
+			}
+		}
+		if location == nil {
+			t.Fatalf("%v: expected the location /foo", tc.name)
+		}
+		if !reflect.DeepEqual(location.ErrorPages, tc.expected) {
+			t.Errorf("%v: expected the error pages %+v but got %+v", tc.name, tc.expected, location.ErrorPages)
+		}
+
+		if tc.event != (len(recorder.Events) > 0) {
+			t.Errorf("%v: expected an event %v but got %v", tc.name, tc.event, len(recorder.Events))
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+)
+
+// Reasons of the events recorded in the Ingress objects to explain
+// problems found building the NGINX configuration
+const (
+	// reasonInvalidAnnotation indicates an annotation of the Ingress cannot be parsed
+	reasonInvalidAnnotation = "InvalidAnnotation"
+	// reasonMissingSecret indicates a secret referenced by the Ingress cannot be loaded
+	reasonMissingSecret = "MissingSecret"
+	// reasonServiceNotFound indicates a service referenced by the Ingress does not exist
+	reasonServiceNotFound = "ServiceNotFound"
+	// reasonPortNotFound indicates a service port referenced by the Ingress does not exist
+	reasonPortNotFound = "PortNotFound"
+	// reasonConfigMapNotFound indicates a ConfigMap referenced by the Ingress does not exist
+	reasonConfigMapNotFound = "ConfigMapNotFound"
+	// reasonCanaryWithoutPrimary indicates a canary Ingress path does not match the path of other Ingress
+	reasonCanaryWithoutPrimary = "CanaryWithoutPrimary"
+	// reasonConflict indicates a host and path of the Ingress is already defined by an older Ingress
+	reasonConflict = "Conflict"
+	// reasonHostNotAllowed indicates a host of the Ingress cannot be used in its namespace
+	reasonHostNotAllowed = "HostNotAllowed"
+	// reasonReloadFailed indicates the configuration that includes the Ingress was rejected by NGINX
+	reasonReloadFailed = "ReloadFailed"
+	// reasonExcluded indicates the Ingress was removed from the configuration because it is not valid
+	reasonExcluded = "Excluded"
+	// reasonSynced indicates the Ingress is included in the running NGINX configuration
+	reasonSynced = "Synced"
+)
+
+// recordIngressesEvent records the same event in a list of Ingress objects
+func (n *NGINXController) recordIngressesEvent(ings []*networking.Ingress, eventType, reason, messageFmt string, args ...interface{}) {
+	for _, ing := range ings {
+		n.recorder.Eventf(ing, eventType, reason, messageFmt, args...)
+	}
+}
+
+// recordWarning records a Warning event in an Ingress
+func (n *NGINXController) recordWarning(ing *networking.Ingress, reason, messageFmt string, args ...interface{}) {
+	n.recorder.Eventf(ing, apiv1.EventTypeWarning, reason, messageFmt, args...)
+}
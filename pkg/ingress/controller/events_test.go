@@ -0,0 +1,96 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
+
+	"github.com/stolostron/management-ingress/pkg/ingress"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/class"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+)
+
+func TestIngressEvents(t *testing.T) {
+	ic := class.IngressClass
+	class.IngressClass = class.DefaultClass
+	defer func() {
+		class.IngressClass = ic
+	}()
+
+	invalidAnns := map[string]string{parser.GetAnnotationWithPrefix("auth-type"): "basic"}
+
+	testCases := []struct {
+		name   string
+		run    func(n *NGINXController)
+		events []string
+	}{
+		{
+			"invalid annotation",
+			func(n *NGINXController) {
+				n.extractAnnotations(buildIngressForChecker(class.DefaultClass, invalidAnns))
+			},
+			[]string{"Warning InvalidAnnotation"},
+		},
+		{
+			"service not found",
+			func(n *NGINXController) {
+				ing := buildIngressForChecker(class.DefaultClass, nil)
+				n.extractAnnotations(ing)
+				n.createUpstreams([]*networking.Ingress{ing}, &ingress.Backend{})
+			},
+			[]string{"Warning ServiceNotFound service default/foo referenced by path /foo not found"},
+		},
+		{
+			"missing secret",
+			func(n *NGINXController) {
+				n.checkMissingSecret(buildIngressForChecker(class.DefaultClass, nil), "default/foo-tls")
+			},
+			[]string{"Warning MissingSecret secret default/foo-tls not found"},
+		},
+		{
+			"reload failed",
+			func(n *NGINXController) {
+				ing := buildIngressForChecker(class.DefaultClass, nil)
+				if err := n.listers.Ingress.Add(ing); err != nil {
+					t.Fatalf("unexpected error adding ingress: %v", err)
+				}
+				n.extractAnnotations(ing)
+				if err := n.syncIngress(ing); err == nil {
+					t.Errorf("expected an error reloading the configuration")
+				}
+			},
+			[]string{"Warning ServiceNotFound", "Warning ReloadFailed"},
+		},
+	}
+
+	for _, tc := range testCases {
+		n := buildControllerForChecker(t, "/bin/false")
+		recorder := record.NewFakeRecorder(10)
+		n.recorder = recorder
+		n.syncRateLimiter = flowcontrol.NewFakeAlwaysRateLimiter()
+		n.runningConfig = &ingress.Configuration{}
+
+		tc.run(n)
+		close(recorder.Events)
+
+		events := []string{}
+		for event := range recorder.Events {
+			events = append(events, event)
+		}
+
+		if len(events) != len(tc.events) {
+			t.Fatalf("%v: expected events %v but got %v", tc.name, tc.events, events)
+		}
+		for i, prefix := range tc.events {
+			if !strings.HasPrefix(events[i], prefix) {
+				t.Errorf("%v: expected event starting with %q but got %q", tc.name, prefix, events[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/golang/glog"
+
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations"
+	ngx_config "github.com/stolostron/management-ingress/pkg/ingress/controller/config"
+)
+
+var (
+	headerNameRegex = regexp.MustCompile(`^[a-zA-Z\d\-_]+$`)
+	// the values are rendered in quoted directives
+	headerValueRegex = regexp.MustCompile(`^[^"\\\r\n]*$`)
+)
+
+// getSecurityHeaders returns the security headers of the responses of the
+// locations of an Ingress. The annotations of the Ingress override the
+// values of the configuration.
+func getSecurityHeaders(cfg ngx_config.Configuration, anns *annotations.Ingress) ingress.SecurityHeaders {
+	sh := anns.SecurityHeaders
+	if sh.XFrameOptions != nil {
+		cfg.XFrameOptions = *sh.XFrameOptions
+	}
+	if sh.ContentSecurityPolicy != nil {
+		cfg.ContentSecurityPolicy = *sh.ContentSecurityPolicy
+	}
+	if sh.HSTS != nil {
+		cfg.HSTS = *sh.HSTS
+	}
+	if sh.HSTSMaxAge != nil {
+		cfg.HSTSMaxAge = *sh.HSTSMaxAge
+	}
+	if sh.HSTSIncludeSubdomains != nil {
+		cfg.HSTSIncludeSubdomains = *sh.HSTSIncludeSubdomains
+	}
+	if sh.HSTSPreload != nil {
+		cfg.HSTSPreload = *sh.HSTSPreload
+	}
+
+	return cfg.SecurityHeaders()
+}
+
+// getAddHeaders returns the headers added to the responses, read from the
+// ConfigMap referenced by the key add-headers of the configuration with the
+// format namespace/name
+func (n *NGINXController) getAddHeaders(cfg ngx_config.Configuration) map[string]string {
+	if cfg.AddHeaders == "" {
+		return nil
+	}
+
+	cm, err := n.listers.ConfigMap.GetByName(cfg.AddHeaders)
+	if err != nil {
+		glog.Warningf("error obtaining configmap %v of the add-headers key: %v", cfg.AddHeaders, err)
+		return nil
+	}
+	return readHeaders(cm)
+}
+
+// getProxySetHeaders returns the headers sent to the upstreams, read from the
+// ConfigMap referenced by the key proxy-set-headers of the configuration with
+// the format namespace/name
+func (n *NGINXController) getProxySetHeaders(cfg ngx_config.Configuration) map[string]string {
+	if cfg.ProxySetHeaders == "" {
+		return nil
+	}
+
+	cm, err := n.listers.ConfigMap.GetByName(cfg.ProxySetHeaders)
+	if err != nil {
+		glog.Warningf("error obtaining configmap %v of the proxy-set-headers key: %v", cfg.ProxySetHeaders, err)
+		return nil
+	}
+	return readHeaders(cm)
+}
+
+// getIngressProxySetHeaders returns the headers sent to the upstreams by the
+// locations of an Ingress, read from the ConfigMap of the proxy-set-headers
+// annotation in the namespace of the Ingress. They replace the headers of
+// the configuration with the same name.
+func (n *NGINXController) getIngressProxySetHeaders(ing *networking.Ingress, anns *annotations.Ingress) map[string]string {
+	if anns.ProxySetHeaders == "" {
+		return nil
+	}
+
+	cmKey := fmt.Sprintf("%v/%v", ing.GetNamespace(), anns.ProxySetHeaders)
+	cm, err := n.listers.ConfigMap.GetByName(cmKey)
+	if err != nil {
+		glog.Warningf("error obtaining configmap: %v", err)
+		n.recordWarning(ing, reasonConfigMapNotFound, "configmap %v referenced by the proxy-set-headers annotation not found", cmKey)
+		return nil
+	}
+	return readHeaders(cm)
+}
+
+// readHeaders returns the headers of a ConfigMap, where each key is the name
+// of a header. The headers with an invalid name or value are skipped.
+func readHeaders(cm *apiv1.ConfigMap) map[string]string {
+	headers := map[string]string{}
+	for name, value := range cm.Data {
+		if !headerNameRegex.MatchString(name) || !headerValueRegex.MatchString(value) {
+			glog.Warningf("skipping header %v of configmap %v/%v: invalid name or value", name, cm.Namespace, cm.Name)
+			continue
+		}
+		headers[name] = value
+	}
+	return headers
+}
+
+// isHeadersConfigMap checks if a ConfigMap contains the headers
+// referenced by the configuration, see SetConfig
+func (n *NGINXController) isHeadersConfigMap(mapKey string) bool {
+	return n.headersConfigMaps.Has(mapKey)
+}
+
+// isProxySetHeadersConfigMap checks if a ConfigMap contains the headers
+// of the proxy-set-headers annotation of an Ingress
+func (n *NGINXController) isProxySetHeadersConfigMap(cm *apiv1.ConfigMap) bool {
+	for _, item := range n.listers.IngressAnnotation.List() {
+		anns, ok := item.(*annotations.Ingress)
+		if !ok {
+			continue
+		}
+		if anns.Namespace == cm.Namespace && anns.ProxySetHeaders == cm.Name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/stolostron/management-ingress/pkg/ingress"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/class"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/securityheaders"
+	ngx_template "github.com/stolostron/management-ingress/pkg/ingress/controller/template"
+)
+
+func TestGetSecurityHeaders(t *testing.T) {
+	empty := ""
+	disabled := false
+	csp := "frame-ancestors 'self' https://console.example.com"
+
+	testCases := []struct {
+		name      string
+		configmap map[string]string
+		anns      securityheaders.Config
+		expected  ingress.SecurityHeaders
+	}{
+		{"defaults", nil, securityheaders.Config{}, ingress.SecurityHeaders{
+			XFrameOptions:           "SAMEORIGIN",
+			XContentTypeOptions:     "nosniff",
+			XXSSProtection:          "1; mode=block",
+			StrictTransportSecurity: "max-age=63072000; includeSubDomains",
+		}},
+		{"configuration", map[string]string{
+			"x-frame-options":         "DENY",
+			"x-xss-protection":        "",
+			"hsts-max-age":            "31536000",
+			"hsts-include-subdomains": "false",
+			"hsts-preload":            "true",
+		}, securityheaders.Config{}, ingress.SecurityHeaders{
+			XFrameOptions:           "DENY",
+			XContentTypeOptions:     "nosniff",
+			StrictTransportSecurity: "max-age=31536000; preload",
+		}},
+		{"annotations", map[string]string{"x-frame-options": "DENY"}, securityheaders.Config{
+			XFrameOptions:         &empty,
+			ContentSecurityPolicy: &csp,
+			HSTS:                  &disabled,
+		}, ingress.SecurityHeaders{
+			XContentTypeOptions:   "nosniff",
+			XXSSProtection:        "1; mode=block",
+			ContentSecurityPolicy: csp,
+		}},
+	}
+
+	for _, tc := range testCases {
+		cfg := ngx_template.ReadConfig(tc.configmap)
+
+		headers := getSecurityHeaders(cfg, &annotations.Ingress{SecurityHeaders: tc.anns})
+		if headers != tc.expected {
+			t.Errorf("%v: expected %+v but returned %+v", tc.name, tc.expected, headers)
+		}
+	}
+}
+
+func TestGetAddHeaders(t *testing.T) {
+	n := buildControllerForChecker(t, "/bin/true")
+	err := n.listers.ConfigMap.Add(&apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "headers", Namespace: metav1.NamespaceDefault},
+		Data: map[string]string{
+			"X-Foo":        "bar",
+			"X-Invalid":    `"quoted"`,
+			"invalid name": "foo",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error adding configmap: %v", err)
+	}
+
+	cfg := ngx_template.ReadConfig(nil)
+	if headers := n.getAddHeaders(cfg); headers != nil {
+		t.Errorf("expected no headers without the add-headers key but returned %v", headers)
+	}
+
+	cfg = ngx_template.ReadConfig(map[string]string{"add-headers": "default/missing"})
+	if headers := n.getAddHeaders(cfg); headers != nil {
+		t.Errorf("expected no headers with a missing configmap but returned %v", headers)
+	}
+
+	cfg = ngx_template.ReadConfig(map[string]string{"add-headers": "default/headers"})
+	expected := map[string]string{"X-Foo": "bar"}
+	if headers := n.getAddHeaders(cfg); !reflect.DeepEqual(headers, expected) {
+		t.Errorf("expected %v but returned %v", expected, headers)
+	}
+
+	cfg = ngx_template.ReadConfig(map[string]string{"proxy-set-headers": "default/headers"})
+	if headers := n.getProxySetHeaders(cfg); !reflect.DeepEqual(headers, expected) {
+		t.Errorf("expected %v but returned %v", expected, headers)
+	}
+
+	n.SetConfig(&apiv1.ConfigMap{Data: map[string]string{"proxy-set-headers": "default/headers"}})
+	if !n.isHeadersConfigMap("default/headers") {
+		t.Errorf("expected default/headers to be the configmap of the headers")
+	}
+	if n.isHeadersConfigMap("default/other") {
+		t.Errorf("expected default/other not to be the configmap of the headers")
+	}
+}
+
+func TestGetIngressProxySetHeaders(t *testing.T) {
+	ic := class.IngressClass
+	class.IngressClass = class.DefaultClass
+	defer func() {
+		class.IngressClass = ic
+	}()
+
+	annotation := parser.GetAnnotationWithPrefix("proxy-set-headers")
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    map[string]string
+		event       bool
+	}{
+		{"without annotations", nil, nil, false},
+		{"configmap", map[string]string{annotation: "headers"}, map[string]string{"X-Tenant": "a"}, false},
+		{"missing configmap", map[string]string{annotation: "missing"}, nil, true},
+	}
+
+	for _, tc := range testCases {
+		n := buildControllerForChecker(t, "/bin/true")
+		recorder := record.NewFakeRecorder(10)
+		n.recorder = recorder
+		addServiceForChecker(t, n, "foo")
+		err := n.listers.ConfigMap.Add(&apiv1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "headers", Namespace: metav1.NamespaceDefault},
+			Data:       map[string]string{"X-Tenant": "a"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error adding configmap: %v", err)
+		}
+
+		ing := buildIngressForChecker(class.DefaultClass, tc.annotations)
+		n.extractAnnotations(ing)
+
+		_, servers, _ := n.getBackendServers([]*networking.Ingress{ing}, n.readConfig())
+		var location *ingress.Location
+		for _, loc := range servers[0].Locations {
+			if loc.Path == "/foo" {
+				location = loc
+			}
+		}
+		if location == nil {
+			t.Fatalf("%v: expected the location /foo", tc.name)
+		}
+		if !reflect.DeepEqual(location.ProxySetHeaders, tc.expected) {
+			t.Errorf("%v: expected the headers %v but got %v", tc.name, tc.expected, location.ProxySetHeaders)
+		}
+
+		if tc.event != (len(recorder.Events) > 0) {
+			t.Errorf("%v: expected an event %v but got %v", tc.name, tc.event, len(recorder.Events))
+		}
+
+		cm := &apiv1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "headers", Namespace: metav1.NamespaceDefault}}
+		if n.isProxySetHeadersConfigMap(cm) != (tc.expected != nil) {
+			t.Errorf("%v: expected the configmap of the annotation to be %v", tc.name, tc.expected != nil)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"fmt"
+
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/stolostron/management-ingress/pkg/ingress"
+	ngx_config "github.com/stolostron/management-ingress/pkg/ingress/controller/config"
+)
+
+// invalidConfigurationError indicates the NGINX configuration
+// was rejected running the command "nginx -t"
+type invalidConfigurationError struct {
+	err error
+}
+
+func (e invalidConfigurationError) Error() string {
+	return e.err.Error()
+}
+
+// excludeIngresses removes from a list the Ingresses that were excluded from
+// the configuration in a previous sync and did not change since then. Both
+// the included and the excluded Ingresses are returned.
+func (n *NGINXController) excludeIngresses(ings []*networking.Ingress) ([]*networking.Ingress, []*networking.Ingress) {
+	var included, excluded []*networking.Ingress
+	current := map[string]string{}
+	for _, ing := range ings {
+		key := fmt.Sprintf("%v/%v", ing.Namespace, ing.Name)
+		if version, ok := n.excludedIngresses[key]; ok && version == ing.ResourceVersion {
+			current[key] = version
+			excluded = append(excluded, ing)
+			continue
+		}
+		included = append(included, ing)
+	}
+
+	// forget the Ingresses that were updated or deleted
+	n.excludedIngresses = current
+	return included, excluded
+}
+
+// findInvalidIngresses bisects a list of Ingresses that generates an invalid
+// NGINX configuration and returns the Ingresses that cause the failure.
+// An error is returned if the configuration without Ingresses is not valid,
+// because the failure cannot be fixed excluding Ingresses.
+func (n *NGINXController) findInvalidIngresses(ings []*networking.Ingress, cfg ngx_config.Configuration) ([]*networking.Ingress, error) {
+	// the events about the content of the Ingresses
+	// are recorded in the sync, not in every test
+	quiet := n.newConfigurationBuilder(n.listers, &record.FakeRecorder{})
+
+	if err := quiet.testIngresses(nil, cfg); err != nil {
+		return nil, fmt.Errorf("the configuration without ingresses is not valid: %v", err)
+	}
+
+	// the failure might be caused by something else than the Ingresses
+	if err := quiet.testIngresses(ings, cfg); err == nil {
+		return nil, nil
+	}
+
+	return quiet.bisectIngresses(nil, ings, cfg), nil
+}
+
+// bisectIngresses returns the Ingresses of the candidates that make the
+// configuration invalid. The configuration generated with the good Ingresses
+// must be valid and the one generated adding the candidates must be invalid.
+func (n *NGINXController) bisectIngresses(good, candidates []*networking.Ingress, cfg ngx_config.Configuration) []*networking.Ingress {
+	if len(candidates) == 1 {
+		return candidates
+	}
+
+	half := len(candidates) / 2
+	first, second := candidates[:half], candidates[half:]
+
+	var invalid []*networking.Ingress
+	if n.testIngresses(joinIngresses(good, first), cfg) != nil {
+		invalid = n.bisectIngresses(good, first, cfg)
+		good = joinIngresses(good, removeIngresses(first, invalid))
+		if n.testIngresses(joinIngresses(good, second), cfg) == nil {
+			return invalid
+		}
+	} else {
+		good = joinIngresses(good, first)
+	}
+
+	return append(invalid, n.bisectIngresses(good, second, cfg)...)
+}
+
+// testIngresses checks the NGINX configuration generated
+// with a list of Ingresses running the command "nginx -t"
+func (n *NGINXController) testIngresses(ings []*networking.Ingress, cfg ngx_config.Configuration) error {
+	upstreams, servers, _ := n.getBackendServers(ings, cfg)
+	content, err := n.t.Write(n.buildTemplateConfig(ingress.Configuration{
+		Backends: upstreams,
+		Servers:  servers,
+	}, cfg))
+	if err != nil {
+		return err
+	}
+
+	return n.testTemplate(content)
+}
+
+// joinIngresses returns a new list with the Ingresses of both lists
+func joinIngresses(a, b []*networking.Ingress) []*networking.Ingress {
+	ings := make([]*networking.Ingress, 0, len(a)+len(b))
+	ings = append(ings, a...)
+	return append(ings, b...)
+}
+
+// removeIngresses returns a new list with the Ingresses of ings not present in remove
+func removeIngresses(ings, remove []*networking.Ingress) []*networking.Ingress {
+	var result []*networking.Ingress
+	for _, ing := range ings {
+		found := false
+		for _, r := range remove {
+			if ing == r {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, ing)
+		}
+	}
+	return result
+}
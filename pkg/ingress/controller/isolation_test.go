@@ -0,0 +1,139 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	networking "k8s.io/api/networking/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/class"
+)
+
+// fakeNGINX is a script that rejects the configurations with a broken location
+const fakeNGINX = `#!/bin/sh
+while [ $# -gt 0 ]; do
+	if [ "$1" = "-c" ]; then
+		! grep -q "location /broken" "$2"
+		exit $?
+	fi
+	shift
+done
+`
+
+func buildIngressesForIsolation(paths ...string) []*networking.Ingress {
+	var ings []*networking.Ingress
+	for i, path := range paths {
+		ing := buildIngressForChecker(class.DefaultClass, nil)
+		ing.Name = fmt.Sprintf("ing-%v", i)
+		ing.ResourceVersion = fmt.Sprintf("%v", i+1)
+		ing.Spec.Rules[0].HTTP.Paths[0].Path = path
+		ings = append(ings, ing)
+	}
+	return ings
+}
+
+func TestFindInvalidIngresses(t *testing.T) {
+	ic := class.IngressClass
+	class.IngressClass = class.DefaultClass
+	defer func() {
+		class.IngressClass = ic
+	}()
+
+	dir, err := ioutil.TempDir("", "isolation")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporal directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	binary := filepath.Join(dir, "nginx")
+	if err := ioutil.WriteFile(binary, []byte(fakeNGINX), 0700); err != nil {
+		t.Fatalf("unexpected error writing fake binary: %v", err)
+	}
+
+	testCases := []struct {
+		name    string
+		paths   []string
+		invalid []int
+	}{
+		{"one invalid ingress", []string{"/a", "/broken-b", "/c"}, []int{1}},
+		{"several invalid ingresses", []string{"/a", "/broken-b", "/c", "/broken-d", "/e"}, []int{1, 3}},
+		{"all invalid ingresses", []string{"/broken-a", "/broken-b"}, []int{0, 1}},
+		{"valid ingresses", []string{"/a", "/b"}, []int{}},
+	}
+
+	for _, tc := range testCases {
+		n := buildControllerForChecker(t, binary)
+		addServiceForChecker(t, n, "foo")
+
+		ings := buildIngressesForIsolation(tc.paths...)
+		for _, ing := range ings {
+			n.extractAnnotations(ing)
+		}
+
+		invalid, err := n.findInvalidIngresses(ings, n.readConfig())
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", tc.name, err)
+		}
+
+		var expected []*networking.Ingress
+		for _, i := range tc.invalid {
+			expected = append(expected, ings[i])
+		}
+		if !reflect.DeepEqual(invalid, expected) {
+			t.Errorf("%v: expected invalid ingresses %v but got %v", tc.name, expected, invalid)
+		}
+	}
+
+	n := buildControllerForChecker(t, "/bin/false")
+	if _, err := n.findInvalidIngresses(buildIngressesForIsolation("/a"), n.readConfig()); err == nil {
+		t.Errorf("expected an error when the configuration without ingresses is not valid")
+	}
+}
+
+func TestExcludeIngresses(t *testing.T) {
+	n := &NGINXController{}
+	ings := buildIngressesForIsolation("/a", "/b", "/c")
+	n.excludedIngresses = map[string]string{
+		"default/ing-1": "2",
+		"default/ing-2": "1",
+		"default/other": "1",
+	}
+
+	included, excluded := n.excludeIngresses(ings)
+	if !reflect.DeepEqual(included, []*networking.Ingress{ings[0], ings[2]}) {
+		t.Errorf("unexpected included ingresses %v", included)
+	}
+	if !reflect.DeepEqual(excluded, []*networking.Ingress{ings[1]}) {
+		t.Errorf("unexpected excluded ingresses %v", excluded)
+	}
+	if !reflect.DeepEqual(n.excludedIngresses, map[string]string{"default/ing-1": "2"}) {
+		t.Errorf("expected the updated and deleted ingresses to be removed but got %v", n.excludedIngresses)
+	}
+}
+
+func TestSaveLastGoodConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lastgood")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporal directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "nginx.conf")
+	n := &NGINXController{cfg: &Configuration{LastGoodConfigPath: path}}
+	n.saveLastGoodConfig([]byte("events {}"))
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error reading the last good configuration: %v", err)
+	}
+	if string(content) != "events {}" {
+		t.Errorf("unexpected content %q", content)
+	}
+}
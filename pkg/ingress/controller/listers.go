@@ -37,15 +37,17 @@ import (
 )
 
 type cacheController struct {
-	Ingress   cache.Controller
-	Endpoint  cache.Controller
-	Service   cache.Controller
-	Secret    cache.Controller
-	Configmap cache.Controller
+	Ingress      cache.Controller
+	IngressClass cache.Controller
+	Endpoint     cache.Controller
+	Service      cache.Controller
+	Secret       cache.Controller
+	Configmap    cache.Controller
 }
 
 func (c *cacheController) Run(stopCh chan struct{}) {
 	go c.Ingress.Run(stopCh)
+	go c.IngressClass.Run(stopCh)
 	go c.Endpoint.Run(stopCh)
 	go c.Service.Run(stopCh)
 	go c.Secret.Run(stopCh)
@@ -54,6 +56,7 @@ func (c *cacheController) Run(stopCh chan struct{}) {
 	// Wait for all involved caches to be synced, before processing items from the queue is started
 	if !cache.WaitForCacheSync(stopCh,
 		c.Ingress.HasSynced,
+		c.IngressClass.HasSynced,
 		c.Endpoint.HasSynced,
 		c.Service.HasSynced,
 		c.Secret.HasSynced,
@@ -68,8 +71,7 @@ func (n *NGINXController) createListers(stopCh chan struct{}) (*ingress.StoreLis
 		AddFunc: func(obj interface{}) {
 			addIng := obj.(*networking.Ingress)
 			if !class.IsValid(addIng) {
-				a := addIng.GetAnnotations()[class.IngressKey]
-				glog.Infof("ignoring add for ingress %v based on annotation %v with value %v", addIng.Name, class.IngressKey, a)
+				glog.Infof("ignoring add for ingress %v based on the ingress class", addIng.Name)
 				return
 			}
 
@@ -93,7 +95,7 @@ func (n *NGINXController) createListers(stopCh chan struct{}) (*ingress.StoreLis
 				}
 			}
 			if !class.IsValid(delIng) {
-				glog.Infof("ignoring delete for ingress %v based on annotation %v", delIng.Name, class.IngressKey)
+				glog.Infof("ignoring delete for ingress %v based on the ingress class", delIng.Name)
 				return
 			}
 			n.recorder.Eventf(delIng, apiv1.EventTypeNormal, "DELETE", fmt.Sprintf("Ingress %s/%s", delIng.Namespace, delIng.Name))
@@ -109,12 +111,11 @@ func (n *NGINXController) createListers(stopCh chan struct{}) (*ingress.StoreLis
 			validOld := class.IsValid(oldIng)
 			validCur := class.IsValid(curIng)
 
-			c := curIng.GetAnnotations()[class.IngressKey]
 			if !validOld && validCur {
-				glog.Infof("creating ingress %v/%v based on annotation %v with value '%v'", curIng.Namespace, curIng.Name, class.IngressKey, c)
+				glog.Infof("creating ingress %v/%v based on the ingress class", curIng.Namespace, curIng.Name)
 				n.recorder.Eventf(curIng, apiv1.EventTypeNormal, "CREATE", fmt.Sprintf("Ingress %s/%s", curIng.Namespace, curIng.Name))
 			} else if validOld && !validCur {
-				glog.Infof("removing ingress %v/%v based on annotation %v with value '%v'", curIng.Namespace, curIng.Name, class.IngressKey, c)
+				glog.Infof("removing ingress %v/%v based on the ingress class", curIng.Namespace, curIng.Name)
 				n.recorder.Eventf(curIng, apiv1.EventTypeNormal, "DELETE", fmt.Sprintf("Ingress %s/%s", curIng.Namespace, curIng.Name))
 			} else if validCur && !reflect.DeepEqual(old, cur) {
 				n.recorder.Eventf(curIng, apiv1.EventTypeNormal, "UPDATE", fmt.Sprintf("Ingress %s/%s", curIng.Namespace, curIng.Name))
@@ -125,6 +126,22 @@ func (n *NGINXController) createListers(stopCh chan struct{}) (*ingress.StoreLis
 		},
 	}
 
+	// changes in IngressClasses can modify the list of Ingresses
+	// handled by the controller
+	ingClassEventHandler := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			n.syncIngressClass()
+		},
+		DeleteFunc: func(obj interface{}) {
+			n.syncIngressClass()
+		},
+		UpdateFunc: func(old, cur interface{}) {
+			if !reflect.DeepEqual(old, cur) {
+				n.syncIngressClass()
+			}
+		},
+	}
+
 	secrEventHandler := cache.ResourceEventHandlerFuncs{
 		UpdateFunc: func(old, cur interface{}) {
 			if !reflect.DeepEqual(old, cur) {
@@ -173,6 +190,22 @@ func (n *NGINXController) createListers(stopCh chan struct{}) (*ingress.StoreLis
 		},
 	}
 
+	epEventHandler := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			n.syncQueue.Enqueue(obj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			n.syncQueue.Enqueue(obj)
+		},
+		UpdateFunc: func(old, cur interface{}) {
+			oep := old.(*apiv1.Endpoints)
+			ocur := cur.(*apiv1.Endpoints)
+			if !reflect.DeepEqual(ocur.Subsets, oep.Subsets) {
+				n.syncQueue.Enqueue(cur)
+			}
+		},
+	}
+
 	mapEventHandler := cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			upCmap := obj.(*apiv1.ConfigMap)
@@ -182,6 +215,23 @@ func (n *NGINXController) createListers(stopCh chan struct{}) (*ingress.StoreLis
 				n.SetConfig(upCmap)
 				n.SetForceReload(true)
 			}
+			if n.isErrorPagesConfigMap(upCmap) || n.isProxySetHeadersConfigMap(upCmap) {
+				n.syncQueue.Enqueue(obj)
+			}
+			if n.isHeadersConfigMap(mapKey) {
+				n.SetForceReload(true)
+				n.syncQueue.Enqueue(obj)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			upCmap, ok := obj.(*apiv1.ConfigMap)
+			if ok && (n.isErrorPagesConfigMap(upCmap) || n.isProxySetHeadersConfigMap(upCmap)) {
+				n.syncQueue.Enqueue(obj)
+			}
+			if ok && n.isHeadersConfigMap(fmt.Sprintf("%s/%s", upCmap.Namespace, upCmap.Name)) {
+				n.SetForceReload(true)
+				n.syncQueue.Enqueue(obj)
+			}
 		},
 		UpdateFunc: func(old, cur interface{}) {
 			if !reflect.DeepEqual(old, cur) {
@@ -197,6 +247,22 @@ func (n *NGINXController) createListers(stopCh chan struct{}) (*ingress.StoreLis
 					n.recorder.Eventf(upCmap, apiv1.EventTypeNormal, "UPDATE", fmt.Sprintf("ConfigMap %v", mapKey))
 					n.syncQueue.Enqueue(cur)
 				}
+				// the error pages of the default-backend annotation are rendered in the configuration
+				if n.isErrorPagesConfigMap(upCmap) {
+					glog.V(2).Infof("updating error pages of configmap %v", mapKey)
+					n.syncQueue.Enqueue(cur)
+				}
+				// the headers of the proxy-set-headers annotation are part of the locations
+				if n.isProxySetHeadersConfigMap(upCmap) {
+					glog.V(2).Infof("updating proxy headers of configmap %v", mapKey)
+					n.syncQueue.Enqueue(cur)
+				}
+				// the headers of the configuration are not part of the locations, so a reload is required
+				if n.isHeadersConfigMap(mapKey) {
+					glog.V(2).Infof("updating headers of configmap %v", mapKey)
+					n.SetForceReload(true)
+					n.syncQueue.Enqueue(cur)
+				}
 			}
 		},
 	}
@@ -215,9 +281,14 @@ func (n *NGINXController) createListers(stopCh chan struct{}) (*ingress.StoreLis
 		cache.NewListWatchFromClient(n.cfg.Client.NetworkingV1().RESTClient(), "ingresses", n.cfg.Namespace, fields.Everything()),
 		&networking.Ingress{}, n.cfg.ResyncPeriod, ingEventHandler)
 
+	lister.IngressClass.Store, controller.IngressClass = cache.NewInformer(
+		cache.NewListWatchFromClient(n.cfg.Client.NetworkingV1().RESTClient(), "ingressclasses", apiv1.NamespaceAll, fields.Everything()),
+		&networking.IngressClass{}, n.cfg.ResyncPeriod, ingClassEventHandler)
+	class.IngressClassLister = &lister.IngressClass
+
 	lister.Endpoint.Store, controller.Endpoint = cache.NewInformer(
 		cache.NewListWatchFromClient(n.cfg.Client.CoreV1().RESTClient(), "endpoints", n.cfg.Namespace, fields.Everything()),
-		&apiv1.Endpoints{}, n.cfg.ResyncPeriod, cache.ResourceEventHandlerFuncs{})
+		&apiv1.Endpoints{}, n.cfg.ResyncPeriod, epEventHandler)
 
 	lister.Secret.Store, controller.Secret = cache.NewInformer(
 		cache.NewListWatchFromClient(n.cfg.Client.CoreV1().RESTClient(), "secrets", watchNs, fields.Everything()),
@@ -233,3 +304,18 @@ func (n *NGINXController) createListers(stopCh chan struct{}) (*ingress.StoreLis
 
 	return lister, controller
 }
+
+// syncIngressClass extracts the annotations of the Ingresses handled by the
+// controller after a change in the IngressClasses and triggers a sync.
+func (n *NGINXController) syncIngressClass() {
+	for _, obj := range n.listers.Ingress.List() {
+		ing := obj.(*networking.Ingress)
+		if !class.IsValid(ing) {
+			continue
+		}
+
+		n.extractAnnotations(ing)
+	}
+
+	n.syncQueue.Enqueue(&networking.Ingress{})
+}
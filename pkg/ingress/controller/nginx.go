@@ -29,6 +29,7 @@ import (
 
 	apiv1 "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes/scheme"
 	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -37,11 +38,13 @@ import (
 
 	"github.com/stolostron/management-ingress/pkg/file"
 	"github.com/stolostron/management-ingress/pkg/ingress"
+	"github.com/stolostron/management-ingress/pkg/ingress/admission"
 	"github.com/stolostron/management-ingress/pkg/ingress/annotations"
 	"github.com/stolostron/management-ingress/pkg/ingress/annotations/class"
 	ngx_config "github.com/stolostron/management-ingress/pkg/ingress/controller/config"
 	"github.com/stolostron/management-ingress/pkg/ingress/controller/process"
 	ngx_template "github.com/stolostron/management-ingress/pkg/ingress/controller/template"
+	"github.com/stolostron/management-ingress/pkg/ingress/metric"
 	"github.com/stolostron/management-ingress/pkg/ingress/status"
 	"github.com/stolostron/management-ingress/pkg/ingress/store"
 	ing_net "github.com/stolostron/management-ingress/pkg/net"
@@ -54,6 +57,10 @@ var (
 	tmplPath    = "/opt/ibm/router/nginx/template/nginx.tmpl"
 	cfgPath     = "/opt/ibm/router/nginx/conf/nginx.conf"
 	nginxBinary = "/opt/ibm/router/nginx/sbin/nginx"
+
+	// nginxStatusPath is the location of the stub_status module
+	// in the status server rendered by the template
+	nginxStatusPath = "/nginx_status"
 )
 
 // NewNGINXController creates a new NGINX Ingress controller.
@@ -96,6 +103,8 @@ func NewNGINXController(config *Configuration, fs file.Filesystem) *NGINXControl
 		stopCh:   make(chan struct{}),
 		stopLock: &sync.Mutex{},
 
+		conflicts: newConflictTracker(),
+
 		fileSystem: fs,
 
 		// create an empty configuration.
@@ -108,6 +117,20 @@ func NewNGINXController(config *Configuration, fs file.Filesystem) *NGINXControl
 
 	n.annotations = annotations.NewAnnotationExtractor(n)
 
+	n.metricCollector = metric.NewDummyCollector()
+	if config.MetricsPort > 0 {
+		statusURL := fmt.Sprintf("http://127.0.0.1:%v%v", config.ListenPorts.Status, nginxStatusPath)
+		n.metricCollector = metric.NewCollector(config.MetricsPort, class.IngressClass, statusURL)
+		n.metricCollector.Handle(conflictsPath, n.conflicts)
+	} else {
+		glog.Warning("Prometheus metrics are disabled (flag --metrics-port=0 was specified)")
+	}
+
+	if config.ValidationWebhook != "" {
+		n.validationWebhookServer = admission.NewServer(config.ValidationWebhook,
+			config.ValidationWebhookCertPath, config.ValidationWebhookKeyPath, n)
+	}
+
 	if config.UpdateStatus {
 		n.syncStatus = status.NewStatusSyncer(status.Config{
 			Client:              config.Client,
@@ -115,6 +138,7 @@ func NewNGINXController(config *Configuration, fs file.Filesystem) *NGINXControl
 			ElectionID:          config.ElectionID,
 			IngressClass:        class.IngressClass,
 			DefaultIngressClass: class.DefaultClass,
+			OnLeaderChange:      n.metricCollector.SetLeader,
 		})
 	} else {
 		glog.Warning("Update of ingress status is disabled (flag --update-status=false was specified)")
@@ -173,6 +197,10 @@ type NGINXController struct {
 
 	syncStatus status.Sync
 
+	metricCollector metric.Collector
+
+	validationWebhookServer *admission.Server
+
 	// local store of SSL certificates
 	// (only certificates used in ingress)
 	sslCertTracker *store.SSLCertTracker
@@ -192,11 +220,22 @@ type NGINXController struct {
 	// runningConfig contains the running configuration in the Backend
 	runningConfig *ingress.Configuration
 
+	// excludedIngresses contains the resource version of the Ingresses
+	// that generate an invalid configuration, indexed by namespace/name
+	excludedIngresses map[string]string
+
+	// conflicts contains the hosts and paths of the running
+	// configuration that are defined by more than one Ingress
+	conflicts *conflictTracker
+
 	forceReload int32
 
 	t *ngx_template.Template
 
 	configmap *apiv1.ConfigMap
+	// headersConfigMaps contains the ConfigMaps of the headers
+	// referenced by the keys of the configuration
+	headersConfigMaps sets.String
 
 	binary   string
 	resolver []net.IP
@@ -221,8 +260,7 @@ func (n *NGINXController) Start() {
 		ing := obj.(*networking.Ingress)
 
 		if !class.IsValid(ing) {
-			a := ing.GetAnnotations()[class.IngressKey]
-			glog.Infof("ignoring add for ingress %v based on annotation %v with value %v", ing.Name, class.IngressKey, a)
+			glog.Infof("ignoring add for ingress %v based on the ingress class", ing.Name)
 			continue
 		}
 
@@ -233,8 +271,16 @@ func (n *NGINXController) Start() {
 		go n.syncStatus.Run()
 	}
 
+	go n.metricCollector.Start()
+
+	if n.validationWebhookServer != nil {
+		go n.validationWebhookServer.Start()
+	}
+
 	go wait.Until(n.checkMissingSecrets, 30*time.Second, n.stopCh)
 
+	n.restoreLastGoodConfig()
+
 	done := make(chan error, 1)
 	// #nosec
 	cmd := exec.Command(n.binary, "-c", cfgPath)
@@ -300,6 +346,10 @@ func (n *NGINXController) Stop() error {
 	if n.syncStatus != nil {
 		n.syncStatus.Shutdown()
 	}
+	n.metricCollector.Stop()
+	if n.validationWebhookServer != nil {
+		n.validationWebhookServer.Stop()
+	}
 
 	// Send stop signal to Nginx
 	glog.Info("stopping NGINX process...")
@@ -349,6 +399,13 @@ func (n *NGINXController) SetConfig(cmap *apiv1.ConfigMap) {
 	}
 
 	c := ngx_template.ReadConfig(m)
+	n.headersConfigMaps = sets.NewString()
+	for _, key := range []string{c.AddHeaders, c.ProxySetHeaders} {
+		if key != "" {
+			n.headersConfigMaps.Insert(key)
+		}
+	}
+
 	if c.SSLSessionTicketKey != "" {
 		d, err := base64.StdEncoding.DecodeString(c.SSLSessionTicketKey)
 		if err != nil {
@@ -362,6 +419,15 @@ func (n *NGINXController) SetConfig(cmap *apiv1.ConfigMap) {
 	}
 }
 
+// readConfig returns the configuration of the ConfigMap, or the default
+// configuration when there is no ConfigMap. It is read once per sync.
+func (n *NGINXController) readConfig() ngx_config.Configuration {
+	if n.configmap == nil {
+		return ngx_config.NewDefault()
+	}
+	return ngx_template.ReadConfig(n.configmap.Data)
+}
+
 // OnUpdate is called periodically by syncQueue to keep the configuration in sync.
 //
 // 1. converts configmap configuration to custom configuration object
@@ -370,43 +436,21 @@ func (n *NGINXController) SetConfig(cmap *apiv1.ConfigMap) {
 //
 // returning nill implies the backend will be reloaded.
 // if an error is returned means requeue the update
-func (n *NGINXController) OnUpdate(ingressCfg ingress.Configuration) error {
-	cfg := ngx_template.ReadConfig(n.configmap.Data)
-	cfg.Resolver = n.resolver
-
-	// the limit of open files is per worker process
-	// and we leave some room to avoid consuming all the FDs available
-	wp, err := strconv.Atoi(cfg.WorkerProcesses)
-	glog.V(3).Infof("number of worker processes: %v", wp)
-	if err != nil {
-		wp = 1
-	}
-	maxOpenFiles := (rlimitMaxNumFiles() / wp) - 1024
-	glog.V(3).Infof("maximum number of open file descriptors : %v", rlimitMaxNumFiles())
-	if maxOpenFiles < 1024 {
-		// this means the value of RLIMIT_NOFILE is too low.
-		maxOpenFiles = 1024
-	}
-
-	tc := ngx_config.TemplateConfig{
-		MaxOpenFiles:  maxOpenFiles,
-		BacklogSize:   sysctlSomaxconn(),
-		Backends:      ingressCfg.Backends,
-		Servers:       ingressCfg.Servers,
-		Cfg:           cfg,
-		IsIPV6Enabled: n.isIPV6Enabled && !cfg.DisableIpv6,
-		ListenPorts:   n.cfg.ListenPorts,
-	}
+func (n *NGINXController) OnUpdate(ingressCfg ingress.Configuration, cfg ngx_config.Configuration) error {
+	tc := n.buildTemplateConfig(ingressCfg, cfg)
 
+	start := time.Now()
 	content, err := n.t.Write(tc)
+	n.metricCollector.ObserveTemplateRender(time.Since(start))
 
 	if err != nil {
 		return err
 	}
+	n.metricCollector.SetConfigSize(len(content))
 
 	err = n.testTemplate(content)
 	if err != nil {
-		return err
+		return invalidConfigurationError{err}
 	}
 
 	if glog.V(2) {
@@ -450,9 +494,112 @@ func (n *NGINXController) OnUpdate(ingressCfg ingress.Configuration) error {
 		return fmt.Errorf("%v\n%v", err, string(o))
 	}
 
+	n.saveLastGoodConfig(content)
+
 	return nil
 }
 
+// saveLastGoodConfig persists a configuration accepted by NGINX, so a
+// restarted controller can start from it instead of the default configuration
+func (n *NGINXController) saveLastGoodConfig(content []byte) {
+	if n.cfg.LastGoodConfigPath == "" {
+		return
+	}
+
+	// the file is replaced atomically to avoid partial configurations
+	tmp := n.cfg.LastGoodConfigPath + ".tmp"
+	if err := ioutil.WriteFile(tmp, content, 0600); err != nil {
+		glog.Warningf("unexpected error saving the last good configuration: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, n.cfg.LastGoodConfigPath); err != nil {
+		glog.Warningf("unexpected error saving the last good configuration: %v", err)
+	}
+}
+
+// restoreLastGoodConfig replaces the default NGINX configuration with the
+// last configuration accepted by NGINX, if it exists and it is still valid
+func (n *NGINXController) restoreLastGoodConfig() {
+	if n.cfg.LastGoodConfigPath == "" {
+		return
+	}
+
+	content, err := ioutil.ReadFile(n.cfg.LastGoodConfigPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			glog.Warningf("unexpected error reading the last good configuration: %v", err)
+		}
+		return
+	}
+
+	// the configuration references files, like certificates, that might not exist
+	if err := n.testTemplate(content); err != nil {
+		glog.Warningf("ignoring the last good configuration %v: %v", n.cfg.LastGoodConfigPath, err)
+		return
+	}
+
+	if err := ioutil.WriteFile(cfgPath, content, 0600); err != nil {
+		glog.Warningf("unexpected error restoring the last good configuration: %v", err)
+		return
+	}
+
+	glog.Infof("starting NGINX with the last good configuration %v", n.cfg.LastGoodConfigPath)
+}
+
+// buildTemplateConfig converts the configmap configuration and the ingress
+// configuration to the structure used to render the NGINX template
+func (n *NGINXController) buildTemplateConfig(ingressCfg ingress.Configuration, cfg ngx_config.Configuration) ngx_config.TemplateConfig {
+	cfg.Resolver = n.resolver
+
+	// the limit of open files is per worker process
+	// and we leave some room to avoid consuming all the FDs available
+	wp, err := strconv.Atoi(cfg.WorkerProcesses)
+	glog.V(3).Infof("number of worker processes: %v", wp)
+	if err != nil {
+		wp = 1
+	}
+	maxOpenFiles := (rlimitMaxNumFiles() / wp) - 1024
+	glog.V(3).Infof("maximum number of open file descriptors : %v", rlimitMaxNumFiles())
+	if maxOpenFiles < 1024 {
+		// this means the value of RLIMIT_NOFILE is too low.
+		maxOpenFiles = 1024
+	}
+
+	return ngx_config.TemplateConfig{
+		MaxOpenFiles:    maxOpenFiles,
+		BacklogSize:     sysctlSomaxconn(),
+		Backends:        ingressCfg.Backends,
+		Servers:         ingressCfg.Servers,
+		CustomErrors:    hasErrorPages(ingressCfg.Servers),
+		AddHeaders:      n.getAddHeaders(cfg),
+		ProxySetHeaders: n.getProxySetHeaders(cfg),
+		Cfg:             cfg,
+		IsIPV6Enabled:   n.isIPV6Enabled && !cfg.DisableIpv6,
+		ListenPorts:     n.cfg.ListenPorts,
+
+		RedirectServers:      buildRedirectServers(ingressCfg.Servers),
+		DynamicConfiguration: n.cfg.DynamicConfiguration,
+	}
+}
+
+// newConfigurationBuilder returns a controller that only builds and tests
+// NGINX configurations, using the listers and the recorder received. It
+// receives the read-only dependencies of the controller but not the state
+// of the sync, like the running configuration or the excluded Ingresses.
+func (n *NGINXController) newConfigurationBuilder(listers *ingress.StoreLister, recorder record.EventRecorder) *NGINXController {
+	return &NGINXController{
+		cfg:            n.cfg,
+		listers:        listers,
+		recorder:       recorder,
+		sslCertTracker: n.sslCertTracker,
+		t:              n.t,
+		binary:         n.binary,
+		resolver:       n.resolver,
+		isIPV6Enabled:  n.isIPV6Enabled,
+		fileSystem:     n.fileSystem,
+	}
+}
+
 // testTemplate checks if the NGINX configuration inside the byte array is valid
 // running the command "nginx -t" using a temporal file.
 func (n NGINXController) testTemplate(cfg []byte) error {
@@ -0,0 +1,124 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"strings"
+
+	"github.com/golang/glog"
+
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	ngx_config "github.com/stolostron/management-ingress/pkg/ingress/controller/config"
+)
+
+// hostOwnership maps hostnames and wildcard domains, like *.example.com,
+// to the namespaces allowed to define Ingress rules for them. The hosts
+// that are not included in the policy can be used by any namespace.
+type hostOwnership map[string]sets.String
+
+// parseHostOwnership parses a host ownership policy with the format
+// <host>=<namespace>,<host>=<namespace>. A host can be repeated to allow
+// more than one namespace. Invalid entries are ignored.
+func parseHostOwnership(policy string) hostOwnership {
+	ownership := hostOwnership{}
+	for _, entry := range strings.Split(policy, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, "=")
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			glog.Warningf("ignoring invalid host ownership entry %q, the format is <host>=<namespace>", entry)
+			continue
+		}
+
+		host := strings.ToLower(strings.TrimSpace(parts[0]))
+		if _, ok := ownership[host]; !ok {
+			ownership[host] = sets.NewString()
+		}
+		ownership[host].Insert(strings.TrimSpace(parts[1]))
+	}
+
+	return ownership
+}
+
+// owners returns the namespaces allowed to use a host. The exact host takes
+// precedence over the wildcard domains, and the longest domain is used when
+// more than one matches. A nil set is returned if the host is not restricted.
+func (o hostOwnership) owners(host string) sets.String {
+	host = strings.ToLower(host)
+	if namespaces, ok := o[host]; ok {
+		return namespaces
+	}
+
+	var owners sets.String
+	domain := ""
+	for pattern, namespaces := range o {
+		if !strings.HasPrefix(pattern, "*.") {
+			continue
+		}
+		suffix := pattern[1:]
+		if strings.HasSuffix(host, suffix) && len(suffix) > len(domain) {
+			domain = suffix
+			owners = namespaces
+		}
+	}
+
+	return owners
+}
+
+// allowed returns true if a namespace can define Ingress rules for a host
+func (o hostOwnership) allowed(host, namespace string) bool {
+	owners := o.owners(host)
+	return owners == nil || owners.Has(namespace)
+}
+
+// getHostOwnership returns the host ownership policy of the ConfigMap,
+// or the one of the flag when the ConfigMap does not define it
+func (n *NGINXController) getHostOwnership(cfg ngx_config.Configuration) hostOwnership {
+	policy := n.cfg.HostOwnership
+	if cfg.HostOwnership != "" {
+		policy = cfg.HostOwnership
+	}
+
+	return parseHostOwnership(policy)
+}
+
+// applyHostOwnership removes the rules of the Ingresses that use a host
+// not allowed in their namespace. The Ingresses with removed rules are
+// replaced with copies, the objects of the store are not modified.
+func (n *NGINXController) applyHostOwnership(ings []*networking.Ingress, cfg ngx_config.Configuration) []*networking.Ingress {
+	ownership := n.getHostOwnership(cfg)
+	if len(ownership) == 0 {
+		return ings
+	}
+
+	result := make([]*networking.Ingress, 0, len(ings))
+	for _, ing := range ings {
+		var rules []networking.IngressRule
+		for _, rule := range ing.Spec.Rules {
+			if rule.Host != "" && !ownership.allowed(rule.Host, ing.Namespace) {
+				glog.Warningf("ingress %v/%v rule for host %v is not allowed in namespace %v", ing.Namespace, ing.Name, rule.Host, ing.Namespace)
+				n.recordWarning(ing, reasonHostNotAllowed, "host %v cannot be used in namespace %v, the rule is ignored", rule.Host, ing.Namespace)
+				continue
+			}
+			rules = append(rules, rule)
+		}
+
+		if len(rules) == 0 && len(ing.Spec.Rules) > 0 {
+			// without rules the default backend of the Ingress would be used in all the hosts
+			continue
+		}
+		if len(rules) != len(ing.Spec.Rules) {
+			ing = ing.DeepCopy()
+			ing.Spec.Rules = rules
+		}
+		result = append(result, ing)
+	}
+
+	return result
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/class"
+)
+
+func TestHostOwnership(t *testing.T) {
+	ownership := parseHostOwnership("*.example.com=team-a, *.b.example.com=team-b,*.b.example.com=team-c,console.example.com=console,invalid,=ns")
+
+	testCases := []struct {
+		host      string
+		namespace string
+		allowed   bool
+	}{
+		{"foo.example.com", "team-a", true},
+		{"foo.example.com", "team-b", false},
+		{"foo.bar.example.com", "team-a", true},
+		{"foo.b.example.com", "team-a", false},
+		{"foo.b.example.com", "team-b", true},
+		{"foo.b.example.com", "team-c", true},
+		{"CONSOLE.example.com", "console", true},
+		{"console.example.com", "team-a", false},
+		{"example.com", "team-b", true},
+		{"other.org", "team-b", true},
+	}
+
+	for _, tc := range testCases {
+		if allowed := ownership.allowed(tc.host, tc.namespace); allowed != tc.allowed {
+			t.Errorf("host %v namespace %v: expected allowed %v but got %v", tc.host, tc.namespace, tc.allowed, allowed)
+		}
+	}
+
+	if len(ownership) != 3 {
+		t.Errorf("expected 3 hosts in the policy but got %v", len(ownership))
+	}
+}
+
+func TestApplyHostOwnership(t *testing.T) {
+	ic := class.IngressClass
+	class.IngressClass = class.DefaultClass
+	defer func() {
+		class.IngressClass = ic
+	}()
+
+	n := buildControllerForChecker(t, "/bin/true")
+	n.cfg.HostOwnership = "foo.example.com=other"
+	n.configmap = &apiv1.ConfigMap{Data: map[string]string{"host-ownership": "foo.example.com=default"}}
+	recorder := record.NewFakeRecorder(10)
+	n.recorder = recorder
+	addServiceForChecker(t, n, "foo")
+
+	ing := buildIngressForChecker(class.DefaultClass, nil)
+	ing.Spec.Rules[0].Host = "foo.example.com"
+	ing.Spec.Rules = append(ing.Spec.Rules, *ing.Spec.Rules[0].DeepCopy())
+	ing.Spec.Rules[1].Host = "bar.example.com"
+	n.extractAnnotations(ing)
+
+	// the ConfigMap takes precedence over the flag
+	_, servers, _ := n.getBackendServers([]*networking.Ingress{ing}, n.readConfig())
+	if len(servers) != 3 {
+		t.Fatalf("expected 3 servers but got %v", len(servers))
+	}
+	for _, server := range servers {
+		if server.Hostname != defServerName && server.Namespace != "default" {
+			t.Errorf("expected the server %v to be owned by the namespace default but got %q", server.Hostname, server.Namespace)
+		}
+	}
+
+	n.configmap = &apiv1.ConfigMap{}
+	_, servers, _ = n.getBackendServers([]*networking.Ingress{ing}, n.readConfig())
+	for _, server := range servers {
+		if server.Hostname == "foo.example.com" {
+			t.Errorf("expected the rule for foo.example.com to be dropped")
+		}
+	}
+	if len(servers) != 2 {
+		t.Errorf("expected 2 servers but got %v", len(servers))
+	}
+	if len(ing.Spec.Rules) != 2 {
+		t.Errorf("expected the ingress of the store to be unmodified")
+	}
+
+	close(recorder.Events)
+	event := <-recorder.Events
+	if !strings.HasPrefix(event, "Warning HostNotAllowed host foo.example.com cannot be used in namespace default") {
+		t.Errorf("unexpected event %q", event)
+	}
+}
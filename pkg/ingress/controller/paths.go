@@ -0,0 +1,57 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress"
+)
+
+// normalizePrefixPath returns the NGINX prefix of a path of type Prefix. The
+// path is matched element by element, so /foo matches /foo/bar but not
+// /foobar: a trailing slash is added and the path without it is matched by
+// an exact location (see addPrefixExactLocations).
+func normalizePrefixPath(path string) string {
+	if strings.HasSuffix(path, "/") {
+		return path
+	}
+	return path + "/"
+}
+
+// isExactLocation returns true if the location only matches its path
+func isExactLocation(loc *ingress.Location) bool {
+	return loc.PathType == networking.PathTypeExact
+}
+
+// addPrefixExactLocations adds to a server an exact location for each location
+// of type Prefix, so the path without the trailing slash is also matched. A
+// location of type Exact defined in an Ingress for the same path is preserved.
+func addPrefixExactLocations(server *ingress.Server) {
+	for _, loc := range server.Locations {
+		if loc.PathType != networking.PathTypePrefix || loc.Path == rootLocation {
+			continue
+		}
+
+		path := strings.TrimSuffix(loc.Path, "/")
+
+		found := false
+		for _, l := range server.Locations {
+			if l.Path == path && isExactLocation(l) {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+
+		exact := *loc
+		exact.Path = path
+		exact.PathType = networking.PathTypeExact
+		server.Locations = append(server.Locations, &exact)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	networking "k8s.io/api/networking/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/class"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/parser"
+)
+
+func TestPathTypeLocations(t *testing.T) {
+	ic := class.IngressClass
+	class.IngressClass = class.DefaultClass
+	defer func() {
+		class.IngressClass = ic
+	}()
+
+	exact := networking.PathTypeExact
+	prefix := networking.PathTypePrefix
+	implementationSpecific := networking.PathTypeImplementationSpecific
+
+	testCases := []struct {
+		name      string
+		pathTypes []*networking.PathType
+		paths     []string
+		anns      map[string]string
+		locations []string
+	}{
+		{"nil path type", []*networking.PathType{nil}, []string{"/foo"}, nil, []string{"/foo"}},
+		{"implementation specific", []*networking.PathType{&implementationSpecific}, []string{"/foo"}, nil, []string{"/foo"}},
+		{"exact", []*networking.PathType{&exact}, []string{"/foo"}, nil, []string{"/foo Exact"}},
+		{"prefix", []*networking.PathType{&prefix}, []string{"/foo"}, nil, []string{"/foo/ Prefix", "/foo Exact"}},
+		{"prefix with trailing slash", []*networking.PathType{&prefix}, []string{"/foo/"}, nil, []string{"/foo/ Prefix", "/foo Exact"}},
+		{"prefix root", []*networking.PathType{&prefix}, []string{"/"}, nil, []string{"/ Prefix"}},
+		{"prefix and exact", []*networking.PathType{&prefix, &exact}, []string{"/foo", "/foo"}, nil, []string{"/foo/ Prefix", "/foo Exact"}},
+		{"prefix with location modifier", []*networking.PathType{&prefix}, []string{"/foo"},
+			map[string]string{parser.GetAnnotationWithPrefix("location-modifier"): "="}, []string{"/foo"}},
+	}
+
+	for _, tc := range testCases {
+		n := buildControllerForChecker(t, "/bin/true")
+		addServiceForChecker(t, n, "foo")
+
+		ing := buildIngressForChecker(class.DefaultClass, tc.anns)
+		ing.Spec.Rules[0].HTTP.Paths = nil
+		for i, path := range tc.paths {
+			ing.Spec.Rules[0].HTTP.Paths = append(ing.Spec.Rules[0].HTTP.Paths, networking.HTTPIngressPath{
+				Path:     path,
+				PathType: tc.pathTypes[i],
+				Backend: networking.IngressBackend{
+					Service: &networking.IngressServiceBackend{
+						Name: "foo",
+						Port: networking.ServiceBackendPort{Number: 80},
+					},
+				},
+			})
+		}
+		n.extractAnnotations(ing)
+
+		_, servers, _ := n.getBackendServers([]*networking.Ingress{ing}, n.readConfig())
+
+		var locations []string
+		for _, server := range servers {
+			if server.Hostname != defServerName {
+				continue
+			}
+			for _, loc := range server.Locations {
+				if loc.Ingress == nil {
+					continue
+				}
+				l := loc.Path
+				if loc.PathType != "" && loc.PathType != networking.PathTypeImplementationSpecific {
+					l = fmt.Sprintf("%v %v", loc.Path, loc.PathType)
+				}
+				locations = append(locations, l)
+			}
+		}
+
+		if !reflect.DeepEqual(locations, tc.locations) {
+			t.Errorf("%v: expected locations %v but got %v", tc.name, tc.locations, locations)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"strings"
+
+	"github.com/golang/glog"
+
+	"github.com/stolostron/management-ingress/pkg/ingress"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations"
+	ngx_config "github.com/stolostron/management-ingress/pkg/ingress/controller/config"
+)
+
+// getSSLRedirect returns if the HTTP requests of the locations of an Ingress
+// are redirected to HTTPS, and if they are redirected even without a
+// certificate. The configuration is used when the Ingress does not define
+// the annotations.
+func getSSLRedirect(cfg ngx_config.Configuration, anns *annotations.Ingress) (bool, bool) {
+	sslRedirect, forceSSLRedirect := cfg.SSLRedirect, cfg.ForceSSLRedirect
+	if anns.SSLRedirect.SSLRedirect != nil {
+		sslRedirect = *anns.SSLRedirect.SSLRedirect
+	}
+	if anns.SSLRedirect.ForceSSLRedirect != nil {
+		forceSSLRedirect = *anns.SSLRedirect.ForceSSLRedirect
+	}
+	return sslRedirect, forceSSLRedirect
+}
+
+// buildRedirectServers returns the hosts redirected to the servers with a
+// location that uses the from-to-www-redirect annotation. The host with the
+// prefix www. is redirected to the one without it, and the other way around.
+// The hosts that already have a server are not redirected.
+func buildRedirectServers(servers []*ingress.Server) map[string]string {
+	hostnames := map[string]bool{}
+	for _, server := range servers {
+		hostnames[server.Hostname] = true
+	}
+
+	redirectServers := map[string]string{}
+	for _, server := range servers {
+		if server.Hostname == defServerName {
+			continue
+		}
+
+		for _, location := range server.Locations {
+			if !location.Redirect.FromToWWW {
+				continue
+			}
+
+			from := "www." + server.Hostname
+			if strings.HasPrefix(server.Hostname, "www.") {
+				from = strings.TrimPrefix(server.Hostname, "www.")
+			}
+
+			if hostnames[from] {
+				glog.V(3).Infof("host %v already has a server, it is not redirected to %v", from, server.Hostname)
+				break
+			}
+
+			redirectServers[from] = server.Hostname
+			break
+		}
+	}
+
+	return redirectServers
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stolostron/management-ingress/pkg/ingress"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/redirect"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/sslredirect"
+	ngx_template "github.com/stolostron/management-ingress/pkg/ingress/controller/template"
+)
+
+func TestGetSSLRedirect(t *testing.T) {
+	enabled := true
+	disabled := false
+
+	testCases := []struct {
+		name      string
+		configmap map[string]string
+		ssl       sslredirect.Config
+		expected  bool
+		force     bool
+	}{
+		{"default", nil, sslredirect.Config{}, false, false},
+		{"configuration", map[string]string{"ssl-redirect": "true", "force-ssl-redirect": "true"}, sslredirect.Config{}, true, true},
+		{"annotation", nil, sslredirect.Config{SSLRedirect: &enabled}, true, false},
+		{"annotation over configuration", map[string]string{"ssl-redirect": "true"}, sslredirect.Config{SSLRedirect: &disabled, ForceSSLRedirect: &enabled}, false, true},
+	}
+
+	for _, tc := range testCases {
+		cfg := ngx_template.ReadConfig(tc.configmap)
+
+		ssl, force := getSSLRedirect(cfg, &annotations.Ingress{SSLRedirect: tc.ssl})
+		if ssl != tc.expected || force != tc.force {
+			t.Errorf("%v: expected %v and %v but returned %v and %v", tc.name, tc.expected, tc.force, ssl, force)
+		}
+	}
+}
+
+func TestBuildRedirectServers(t *testing.T) {
+	fromToWWW := []*ingress.Location{{Path: "/", Redirect: redirect.Config{FromToWWW: true}}}
+	servers := []*ingress.Server{
+		{Hostname: "_", Locations: fromToWWW},
+		{Hostname: "example.com", Locations: fromToWWW},
+		{Hostname: "www.example.org", Locations: fromToWWW},
+		// the host with the prefix www. already has a server
+		{Hostname: "example.net", Locations: fromToWWW},
+		{Hostname: "www.example.net", Locations: []*ingress.Location{{Path: "/"}}},
+		{Hostname: "example.io", Locations: []*ingress.Location{{Path: "/"}}},
+	}
+
+	expected := map[string]string{
+		"www.example.com": "example.com",
+		"example.org":     "www.example.org",
+	}
+	if redirectServers := buildRedirectServers(servers); !reflect.DeepEqual(redirectServers, expected) {
+		t.Errorf("expected %v but returned %v", expected, redirectServers)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/golang/glog"
+
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	cache_client "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/stolostron/management-ingress/pkg/ingress"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/class"
+	ngx_template "github.com/stolostron/management-ingress/pkg/ingress/controller/template"
+	"github.com/stolostron/management-ingress/pkg/ingress/metric"
+	"github.com/stolostron/management-ingress/pkg/ingress/store"
+	"github.com/stolostron/management-ingress/pkg/task"
+)
+
+const (
+	// the limits of the pod running NGINX are unknown when the configuration
+	// is rendered offline, so the minimum values used by the controller are used
+	renderMaxOpenFiles = 1024
+	renderBacklogSize  = 511
+)
+
+// Render generates the NGINX configuration for a static list of Kubernetes
+// objects (Ingress, IngressClass, Service, Endpoints, Secret and ConfigMap)
+// without a connection to a Kubernetes cluster. The objects are loaded in
+// in-memory listers and the configuration is created using the same code path
+// as the running controller. If test is true the configuration is validated
+// running the command "nginx -t".
+func Render(config *Configuration, objects []runtime.Object, t *ngx_template.Template, test bool) ([]byte, error) {
+	ngx := os.Getenv("NGINX_BINARY")
+	if ngx == "" {
+		ngx = nginxBinary
+	}
+
+	n := &NGINXController{
+		binary: ngx,
+		cfg:    config,
+
+		configmap: &apiv1.ConfigMap{},
+
+		sslCertTracker:  store.NewSSLCertTracker(),
+		metricCollector: metric.NewDummyCollector(),
+		recorder:        &record.FakeRecorder{},
+
+		t: t,
+
+		runningConfig: &ingress.Configuration{},
+	}
+
+	// the queue is never started, it only receives the
+	// notifications about new secrets
+	n.syncQueue = task.NewTaskQueue(n.syncIngress)
+
+	n.listers = newStaticListers()
+	class.IngressClassLister = &n.listers.IngressClass
+
+	for _, obj := range objects {
+		if err := n.addStaticObject(obj); err != nil {
+			return nil, err
+		}
+	}
+
+	n.annotations = annotations.NewAnnotationExtractor(n)
+
+	ingresses := n.getValidIngresses()
+	for _, ing := range ingresses {
+		n.readSecrets(ing)
+		n.extractAnnotations(ing)
+	}
+
+	cfg := n.readConfig()
+	upstreams, servers, _ := n.getBackendServers(ingresses, cfg)
+
+	tc := n.buildTemplateConfig(ingress.Configuration{
+		Backends: upstreams,
+		Servers:  servers,
+	}, cfg)
+	tc.MaxOpenFiles = renderMaxOpenFiles
+	tc.BacklogSize = renderBacklogSize
+
+	content, err := n.t.Write(tc)
+	if err != nil {
+		return nil, err
+	}
+
+	if test {
+		if err := n.testTemplate(content); err != nil {
+			return nil, err
+		}
+	}
+
+	return content, nil
+}
+
+// newStaticListers creates empty listers that are not backed by informers
+func newStaticListers() *ingress.StoreLister {
+	lister := &ingress.StoreLister{}
+	lister.Ingress.Store = cache_client.NewStore(cache_client.MetaNamespaceKeyFunc)
+	lister.IngressClass.Store = cache_client.NewStore(cache_client.MetaNamespaceKeyFunc)
+	lister.Service.Store = cache_client.NewStore(cache_client.MetaNamespaceKeyFunc)
+	lister.Endpoint.Store = cache_client.NewStore(cache_client.MetaNamespaceKeyFunc)
+	lister.Secret.Store = cache_client.NewStore(cache_client.MetaNamespaceKeyFunc)
+	lister.ConfigMap.Store = cache_client.NewStore(cache_client.MetaNamespaceKeyFunc)
+	lister.IngressAnnotation.Store = cache_client.NewStore(cache_client.DeletionHandlingMetaNamespaceKeyFunc)
+	return lister
+}
+
+// addStaticObject adds a Kubernetes object to the corresponding lister
+func (n *NGINXController) addStaticObject(obj runtime.Object) error {
+	var err error
+	switch o := obj.(type) {
+	case *networking.Ingress:
+		err = n.listers.Ingress.Add(o)
+	case *networking.IngressClass:
+		err = n.listers.IngressClass.Add(o)
+	case *apiv1.Service:
+		err = n.listers.Service.Add(o)
+	case *apiv1.Endpoints:
+		err = n.listers.Endpoint.Add(o)
+	case *apiv1.Secret:
+		err = n.listers.Secret.Add(o)
+	case *apiv1.ConfigMap:
+		err = n.listers.ConfigMap.Add(o)
+		if fmt.Sprintf("%v/%v", o.Namespace, o.Name) == n.cfg.ConfigMapName {
+			n.configmap = o
+		}
+	default:
+		glog.Warningf("ignoring unsupported object of kind %v", obj.GetObjectKind().GroupVersionKind().Kind)
+	}
+
+	return err
+}
@@ -22,6 +22,7 @@ package template
 import (
 	"fmt"
 	"net"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -29,6 +30,7 @@ import (
 
 	"github.com/mitchellh/mapstructure"
 
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/sourcerange"
 	"github.com/stolostron/management-ingress/pkg/ingress/controller/config"
 	ing_net "github.com/stolostron/management-ingress/pkg/net"
 )
@@ -37,14 +39,25 @@ const (
 	customHTTPErrors     = "custom-http-errors"
 	skipAccessLogUrls    = "skip-access-log-urls"
 	allowlistSourceRange = "allowlist-source-range"
+	denylistSourceRange  = "denylist-source-range"
 	proxyRealIPCIDR      = "proxy-real-ip-cidr"
 	bindAddress          = "bind-address"
 	httpRedirectCode     = "http-redirect-code"
 	proxyStreamResponses = "proxy-stream-responses"
+	proxyHideHeaders     = "proxy-hide-headers"
+	xFrameOptions        = "x-frame-options"
+	hstsMaxAge           = "hsts-max-age"
+	gzipLevel            = "gzip-level"
+	brotliLevel          = "brotli-level"
 )
 
 var (
 	validRedirectCodes = []int{301, 302, 307, 308}
+	headerNameRegex    = regexp.MustCompile(`^[a-zA-Z\d\-_]+$`)
+	// the values of the security headers are rendered in quoted directives
+	headerValueRegex = regexp.MustCompile(`^[^"\\\r\n]*$`)
+	maxAgeRegex      = regexp.MustCompile(`^\d+$`)
+	mimeTypeRegex    = regexp.MustCompile(`^(\*|[a-zA-Z0-9!#$&^_.+-]+/[a-zA-Z0-9!#$&^_.+*-]+)$`)
 )
 
 // ReadConfig obtains the configuration defined by the user merged with the defaults.
@@ -57,6 +70,7 @@ func ReadConfig(src map[string]string) config.Configuration {
 
 	errors := make([]int, 0)
 	allowlist := make([]string, 0)
+	denylist := make([]string, 0)
 	proxylist := make([]string, 0)
 	bindAddressIpv4List := make([]string, 0)
 	bindAddressIpv6List := make([]string, 0)
@@ -75,7 +89,21 @@ func ReadConfig(src map[string]string) config.Configuration {
 	}
 	if val, ok := conf[allowlistSourceRange]; ok {
 		delete(conf, allowlistSourceRange)
-		allowlist = append(allowlist, strings.Split(val, ",")...)
+		ranges, err := sourcerange.ParseSourceRange(val)
+		if err != nil {
+			glog.Warningf("%v is not a valid list of CIDRs: %v", val, err)
+		} else {
+			allowlist = append(allowlist, ranges...)
+		}
+	}
+	if val, ok := conf[denylistSourceRange]; ok {
+		delete(conf, denylistSourceRange)
+		ranges, err := sourcerange.ParseSourceRange(val)
+		if err != nil {
+			glog.Warningf("%v is not a valid list of CIDRs: %v", val, err)
+		} else {
+			denylist = append(denylist, ranges...)
+		}
 	}
 	if val, ok := conf[proxyRealIPCIDR]; ok {
 		delete(conf, proxyRealIPCIDR)
@@ -113,6 +141,27 @@ func ReadConfig(src map[string]string) config.Configuration {
 		}
 	}
 
+	hideHeaders := make([]string, 0)
+	if val, ok := conf[proxyHideHeaders]; ok {
+		delete(conf, proxyHideHeaders)
+		for _, header := range strings.Split(val, ",") {
+			header = strings.TrimSpace(header)
+			if header == "" {
+				continue
+			}
+			if !headerNameRegex.MatchString(header) {
+				glog.Warningf("%v is not a valid header name", header)
+				continue
+			}
+			// the compressed responses of the upstreams need the header
+			if strings.EqualFold(header, "Content-Encoding") {
+				glog.Warningf("header %v cannot be removed from the upstream responses", header)
+				continue
+			}
+			hideHeaders = append(hideHeaders, header)
+		}
+	}
+
 	streamResponses := 1
 	if val, ok := conf[proxyStreamResponses]; ok {
 		delete(conf, proxyStreamResponses)
@@ -125,11 +174,69 @@ func ReadConfig(src map[string]string) config.Configuration {
 	}
 
 	to := config.NewDefault()
+	to.CustomHTTPErrors = filterErrors(errors)
+	to.AllowlistSourceRange = allowlist
+	to.DenylistSourceRange = denylist
 	to.ProxyRealIPCIDR = proxylist
 	to.BindAddressIpv4 = bindAddressIpv4List
 	to.BindAddressIpv6 = bindAddressIpv6List
 	to.HTTPRedirectCode = redirectCode
 	to.ProxyStreamResponses = streamResponses
+	to.ProxyHideHeaders = hideHeaders
+
+	// the invalid values of the security headers keep the defaults
+	if val, ok := conf[xFrameOptions]; ok {
+		delete(conf, xFrameOptions)
+		val = strings.ToUpper(strings.TrimSpace(val))
+		if val == "" || val == "DENY" || val == "SAMEORIGIN" {
+			to.XFrameOptions = val
+		} else {
+			glog.Warningf("%v is not a valid value of %v, using the default %v", val, xFrameOptions, to.XFrameOptions)
+		}
+	}
+	securityHeaders := map[string]*string{
+		"x-content-type-options":  &to.XContentTypeOptions,
+		"x-xss-protection":        &to.XXSSProtection,
+		"content-security-policy": &to.ContentSecurityPolicy,
+	}
+	for key, value := range securityHeaders {
+		if val, ok := conf[key]; ok {
+			delete(conf, key)
+			if headerValueRegex.MatchString(val) {
+				*value = val
+			} else {
+				glog.Warningf("%v is not a valid value of %v, using the default %v", val, key, *value)
+			}
+		}
+	}
+	if val, ok := conf[hstsMaxAge]; ok {
+		delete(conf, hstsMaxAge)
+		if maxAgeRegex.MatchString(val) {
+			to.HSTSMaxAge = val
+		} else {
+			glog.Warningf("%v is not a valid value of %v, using the default %v", val, hstsMaxAge, to.HSTSMaxAge)
+		}
+	}
+
+	// the invalid compression levels and types keep the defaults
+	if val, ok := conf[gzipLevel]; ok {
+		delete(conf, gzipLevel)
+		to.GzipLevel = parseCompressionLevel(gzipLevel, val, 1, 9, to.GzipLevel)
+	}
+	if val, ok := conf[brotliLevel]; ok {
+		delete(conf, brotliLevel)
+		to.BrotliLevel = parseCompressionLevel(brotliLevel, val, 0, 11, to.BrotliLevel)
+	}
+	compressionTypes := map[string]*string{
+		"gzip-types":   &to.GzipTypes,
+		"brotli-types": &to.BrotliTypes,
+	}
+	for key, value := range compressionTypes {
+		if val, ok := conf[key]; ok {
+			delete(conf, key)
+			*value = parseCompressionTypes(key, val, *value)
+		}
+	}
 
 	config := &mapstructure.DecoderConfig{
 		Metadata:         nil,
@@ -150,6 +257,36 @@ func ReadConfig(src map[string]string) config.Configuration {
 	return to
 }
 
+// parseCompressionLevel returns the compression level of a key,
+// or the default when it is not a number between min and max
+func parseCompressionLevel(key, val string, min, max, def int) int {
+	level, err := strconv.Atoi(val)
+	if err != nil || level < min || level > max {
+		glog.Warningf("%v is not a valid value of %v, it must be a number between %v and %v. Using the default %v", val, key, min, max, def)
+		return def
+	}
+	return level
+}
+
+// parseCompressionTypes returns the space separated MIME types of a key,
+// or the default when the list is empty or contains an invalid type
+func parseCompressionTypes(key, val, def string) string {
+	types := strings.FieldsFunc(val, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	if len(types) == 0 {
+		glog.Warningf("%v does not contain MIME types, using the default", key)
+		return def
+	}
+	for _, t := range types {
+		if !mimeTypeRegex.MatchString(t) {
+			glog.Warningf("%v is not a valid MIME type of %v, using the default", t, key)
+			return def
+		}
+	}
+	return strings.Join(types, " ")
+}
+
 func filterErrors(codes []int) []int {
 	var fa []int
 	for _, code := range codes {
@@ -47,6 +47,9 @@ func TestMergeConfigMapToStruct(t *testing.T) {
 		"proxy-real-ip-cidr":         "1.1.1.1/8,2.2.2.2/24",
 		"bind-address":               "1.1.1.1,2.2.2.2,3.3.3,2001:db8:a0b:12f0::1,3731:54:65fe:2::a7,33:33:33::33::33",
 		"worker-shutdown-timeout":    "99s",
+		"allowlist-source-range":     "192.168.0.1, 10.0.0.0/8",
+		"denylist-source-range":      "10.1.0.0/16",
+		"proxy-hide-headers":         "X-Powered-By, Server,invalid header,content-encoding",
 	}
 	def := config.NewDefault()
 	def.DisableAccessLog = true
@@ -59,6 +62,10 @@ func TestMergeConfigMapToStruct(t *testing.T) {
 	def.BindAddressIpv4 = []string{"1.1.1.1", "2.2.2.2"}
 	def.BindAddressIpv6 = []string{"[2001:db8:a0b:12f0::1]", "[3731:54:65fe:2::a7]"}
 	def.WorkerShutdownTimeout = "99s"
+	def.AllowlistSourceRange = []string{"10.0.0.0/8", "192.168.0.1"}
+	def.DenylistSourceRange = []string{"10.1.0.0/16"}
+	def.CustomHTTPErrors = []int{300, 400}
+	def.ProxyHideHeaders = []string{"X-Powered-By", "Server"}
 
 	to := ReadConfig(conf)
 	if diff := pretty.Compare(to, def); diff != "" {
@@ -78,6 +85,62 @@ func TestMergeConfigMapToStruct(t *testing.T) {
 	}
 }
 
+func TestInvalidSourceRange(t *testing.T) {
+	to := ReadConfig(map[string]string{"allowlist-source-range": "10.0.0.0/8,10.0.0.0/33"})
+	if len(to.AllowlistSourceRange) != 0 {
+		t.Errorf("expected an invalid allowlist to be ignored but got %v", to.AllowlistSourceRange)
+	}
+}
+
+func TestInvalidSecurityHeaders(t *testing.T) {
+	def := config.NewDefault()
+	to := ReadConfig(map[string]string{
+		"x-frame-options":         "ALLOW-FROM https://example.com",
+		"x-content-type-options":  `nosniff"; add_header X-Foo "bar`,
+		"x-xss-protection":        "0\n",
+		"content-security-policy": `default-src \'self\'`,
+		"hsts-max-age":            "1y",
+	})
+	if to.XFrameOptions != def.XFrameOptions || to.XContentTypeOptions != def.XContentTypeOptions ||
+		to.XXSSProtection != def.XXSSProtection || to.ContentSecurityPolicy != def.ContentSecurityPolicy ||
+		to.HSTSMaxAge != def.HSTSMaxAge {
+		t.Errorf("expected the invalid security headers to keep the defaults but got %+v", to.SecurityHeaders())
+	}
+
+	to = ReadConfig(map[string]string{
+		"x-frame-options":         "deny",
+		"content-security-policy": "default-src 'self'",
+		"hsts-max-age":            "31536000",
+	})
+	if to.XFrameOptions != "DENY" || to.ContentSecurityPolicy != "default-src 'self'" || to.HSTSMaxAge != "31536000" {
+		t.Errorf("unexpected security headers %+v", to.SecurityHeaders())
+	}
+}
+
+func TestInvalidCompression(t *testing.T) {
+	def := config.NewDefault()
+	to := ReadConfig(map[string]string{
+		"gzip-level":   "0",
+		"brotli-level": "12",
+		"gzip-types":   "text/html; gzip_types *",
+		"brotli-types": "",
+	})
+	if to.GzipLevel != def.GzipLevel || to.BrotliLevel != def.BrotliLevel ||
+		to.GzipTypes != def.GzipTypes || to.BrotliTypes != def.BrotliTypes {
+		t.Errorf("expected the invalid compression keys to keep the defaults but got %v %v %q %q", to.GzipLevel, to.BrotliLevel, to.GzipTypes, to.BrotliTypes)
+	}
+
+	to = ReadConfig(map[string]string{
+		"gzip-level":   "9",
+		"brotli-level": "0",
+		"gzip-types":   "text/html,application/json",
+		"brotli-types": "text/*",
+	})
+	if to.GzipLevel != 9 || to.BrotliLevel != 0 || to.GzipTypes != "text/html application/json" || to.BrotliTypes != "text/*" {
+		t.Errorf("unexpected compression keys %v %v %q %q", to.GzipLevel, to.BrotliLevel, to.GzipTypes, to.BrotliTypes)
+	}
+}
+
 func TestDefaultLoadBalance(t *testing.T) {
 	conf := map[string]string{}
 	to := ReadConfig(conf)
@@ -17,22 +17,31 @@ limitations under the License.
 package template
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	text_template "text/template"
 
 	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	"github.com/golang/glog"
 	"github.com/pkg/errors"
 
 	"github.com/stolostron/management-ingress/pkg/file"
 	"github.com/stolostron/management-ingress/pkg/ingress"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/backendprotocol"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/sessionaffinity"
 	"github.com/stolostron/management-ingress/pkg/ingress/controller/config"
 	ing_net "github.com/stolostron/management-ingress/pkg/net"
 )
@@ -41,6 +50,7 @@ const (
 	slash         = "/"
 	nonIdempotent = "non_idempotent"
 	defBufferSize = 65535
+	bestHTTPHost  = "$best_http_host"
 )
 
 // Template ...
@@ -113,21 +123,43 @@ var (
 			}
 			return true
 		},
-		"buildLocation":         buildLocation,
-		"buildProxyPass":        buildProxyPass,
-		"buildResolvers":        buildResolvers,
-		"buildUpstreamName":     buildUpstreamName,
-		"buildSSLVeify":         buildSSLVeify,
-		"buildClientCAAuth":     buildClientCAAuth,
-		"getenv":                os.Getenv,
-		"contains":              strings.Contains,
-		"hasPrefix":             strings.HasPrefix,
-		"hasSuffix":             strings.HasSuffix,
-		"toUpper":               strings.ToUpper,
-		"toLower":               strings.ToLower,
-		"buildForwardedFor":     buildForwardedFor,
-		"formatIP":              formatIP,
-		"getIngressInformation": getIngressInformation,
+		"buildLocation":           buildLocation,
+		"buildProxyPass":          buildProxyPass,
+		"usesGRPC":                usesGRPC,
+		"locationModule":          locationModule,
+		"buildResolvers":          buildResolvers,
+		"buildUpstreamName":       buildUpstreamName,
+		"buildCanarySplits":       buildCanarySplits,
+		"buildExternalUpstream":   buildExternalUpstream,
+		"buildUpstreamHostHeader": buildUpstreamHostHeader,
+		"buildCanaryUpstream":     buildCanaryUpstream,
+		"buildRateLimitZones":     buildRateLimitZones,
+		"buildRateLimit":          buildRateLimit,
+		"buildSourceRanges":       buildSourceRanges,
+		"buildAuthLocation":       buildAuthLocation,
+		"buildAffinityCookies":    buildAffinityCookies,
+		"buildAuthHeaders":        buildAuthHeaders,
+		"buildAuthSignURL":        buildAuthSignURL,
+		"needsAuthCache":          needsAuthCache,
+		"buildErrorPages":         buildErrorPages,
+		"buildResponseHeaders":    buildResponseHeaders,
+		"buildProxySetHeaders":    buildProxySetHeaders,
+		"buildCompression":        buildCompression,
+		"findServer":              findServer,
+		"errorPageLocations":      errorPageLocations,
+		"buildSourceRangeCheck":   buildSourceRangeCheck,
+		"readyEndpoints":          readyEndpoints,
+		"buildSSLVeify":           buildSSLVeify,
+		"buildClientCAAuth":       buildClientCAAuth,
+		"getenv":                  os.Getenv,
+		"contains":                strings.Contains,
+		"hasPrefix":               strings.HasPrefix,
+		"hasSuffix":               strings.HasSuffix,
+		"toUpper":                 strings.ToUpper,
+		"toLower":                 strings.ToLower,
+		"buildForwardedFor":       buildForwardedFor,
+		"formatIP":                formatIP,
+		"getIngressInformation":   getIngressInformation,
 		"serverConfig": func(all config.TemplateConfig, server *ingress.Server) interface{} {
 			return struct{ First, Second interface{} }{all, server}
 		},
@@ -191,6 +223,9 @@ func buildLocation(input interface{}) string {
 		}
 		return fmt.Sprintf("%s %s", location.LocationModifier, path)
 	}
+	if location.PathType == networking.PathTypeExact {
+		return fmt.Sprintf("= %s", path)
+	}
 	if len(location.Rewrite.Target) > 0 && location.Rewrite.Target != path {
 		if path == slash {
 			return fmt.Sprintf("~* %s", path)
@@ -226,10 +261,15 @@ func buildSSLVeify(b interface{}, loc interface{}) string {
 	for _, backend := range backends {
 		if backend.Name == location.Backend {
 			if backend.Secure {
+				module := proxyModule(backend)
 				if backend.SecureCACert.Secret == "" {
-					sslBlock = "proxy_ssl_verify off;"
+					sslBlock = fmt.Sprintf("%s_ssl_verify off;", module)
 				} else {
-					sslBlock = fmt.Sprintf("proxy_ssl_trusted_certificate %s;", backend.SecureCACert.CAFileName)
+					sslBlock = fmt.Sprintf("%s_ssl_trusted_certificate %s;", module, backend.SecureCACert.CAFileName)
+				}
+				// the certificate of an external service is issued for its name
+				if backend.ExternalName != "" {
+					sslBlock = fmt.Sprintf("%s\n\t    %s_ssl_server_name on;\n\t    %s_ssl_name %s;", sslBlock, module, module, backend.ExternalName)
 				}
 			}
 
@@ -260,10 +300,11 @@ func buildClientCAAuth(b interface{}, loc interface{}) string {
 		if backend.Name == location.Backend {
 			if backend.Secure {
 				if backend.ClientCACert.Secret != "" {
+					module := proxyModule(backend)
 					sslProxyBlock = fmt.Sprintf(`
-	    proxy_ssl_certificate %s;
-	    proxy_ssl_certificate_key %s;
-	    `, backend.ClientCACert.PemFileName, backend.ClientCACert.PemFileName)
+	    %s_ssl_certificate %s;
+	    %s_ssl_certificate_key %s;
+	    `, module, backend.ClientCACert.PemFileName, module, backend.ClientCACert.PemFileName)
 				}
 			}
 
@@ -295,11 +336,36 @@ func buildProxyPass(host string, b interface{}, loc interface{}) string {
 	proto := "http"
 
 	upstreamName := location.Backend
+	if location.CanaryBackend != "" {
+		// the upstream is selected in the location, see buildCanaryUpstream
+		upstreamName = "$proxy_upstream_name"
+	}
 	for _, backend := range backends {
 		if backend.Name == location.Backend {
 			if backend.Secure {
 				proto = "https"
 			}
+			if backend.ExternalName != "" {
+				// a variable forces NGINX to resolve the name, see buildExternalUpstream
+				upstreamName = "$proxy_upstream_host"
+			}
+
+			// the gRPC module does not change the URI of the request,
+			// so the rewrite annotations and the upstream URI are ignored
+			if proxyModule(backend) == "grpc" {
+				proto = "grpc"
+				if backend.BackendProtocol == backendprotocol.GRPCS {
+					proto = "grpcs"
+				}
+				grpcPass := fmt.Sprintf("grpc_pass %s://%s;", proto, upstreamName)
+				if location.XForwardedPrefix && location.Rewrite.Target != "" && path != location.Rewrite.Target {
+					if !strings.HasSuffix(path, slash) {
+						path = fmt.Sprintf("%s/", path)
+					}
+					grpcPass = fmt.Sprintf("grpc_set_header X-Forwarded-Prefix \"%s\";\n\t    %s", path, grpcPass)
+				}
+				return grpcPass
+			}
 
 			break
 		}
@@ -314,6 +380,12 @@ func buildProxyPass(host string, b interface{}, loc interface{}) string {
 
 	if location.UpstreamURI != "" {
 		defProxyPass = fmt.Sprintf("proxy_pass %s://%s%s;", proto, upstreamName, location.UpstreamURI)
+		if strings.HasPrefix(upstreamName, "$") {
+			// the URI of a proxy_pass with variables replaces the whole
+			// request URI, so the path of the location is replaced instead
+			defProxyPass = fmt.Sprintf(`rewrite ^%s(.*) %s$1 break;
+	    proxy_pass %s://%s;`, regexp.QuoteMeta(location.Path), location.UpstreamURI, proto, upstreamName)
+		}
 	}
 
 	if !strings.HasSuffix(path, slash) {
@@ -360,6 +432,46 @@ func buildProxyPass(host string, b interface{}, loc interface{}) string {
 	return defProxyPass
 }
 
+// proxyModule returns the NGINX module that sends the requests to the
+// endpoints of a backend, which is the prefix of its directives
+func proxyModule(backend *ingress.Backend) string {
+	if backendprotocol.IsGRPC(backend.BackendProtocol) {
+		return "grpc"
+	}
+	return "proxy"
+}
+
+// usesGRPC returns true if the requests of a location are sent
+// to the endpoints of its backend with the gRPC module
+func usesGRPC(b interface{}, loc interface{}) bool {
+	return locationModule(b, loc) == "grpc"
+}
+
+// locationModule returns the NGINX module that sends the requests of a
+// location to its backend, which is the prefix of the directives that
+// set the headers of the upstream requests
+func locationModule(b interface{}, loc interface{}) string {
+	backends, ok := b.([]*ingress.Backend)
+	if !ok {
+		glog.Errorf("expected an '[]*ingress.Backend' type but %T was returned", b)
+		return "proxy"
+	}
+
+	location, ok := loc.(*ingress.Location)
+	if !ok {
+		glog.Errorf("expected a '*ingress.Location' type but %T was returned", loc)
+		return "proxy"
+	}
+
+	for _, backend := range backends {
+		if backend.Name == location.Backend {
+			return proxyModule(backend)
+		}
+	}
+
+	return "proxy"
+}
+
 func isValidClientBodyBufferSize(input interface{}) bool {
 	s, ok := input.(string)
 	if !ok {
@@ -394,6 +506,25 @@ func isValidClientBodyBufferSize(input interface{}) bool {
 	return true
 }
 
+// readyEndpoints returns the endpoints of a backend that passed the
+// readiness checks and can receive traffic
+func readyEndpoints(input interface{}) []ingress.Endpoint {
+	backend, ok := input.(*ingress.Backend)
+	if !ok {
+		glog.Errorf("expected an '*ingress.Backend' type but %T was returned", input)
+		return []ingress.Endpoint{}
+	}
+
+	endpoints := []ingress.Endpoint{}
+	for _, ep := range backend.Endpoints {
+		if ep.Ready {
+			endpoints = append(endpoints, ep)
+		}
+	}
+
+	return endpoints
+}
+
 // TODO: Needs Unit Tests
 func buildUpstreamName(host string, b interface{}, loc interface{}) string {
 	location, ok := loc.(*ingress.Location)
@@ -406,6 +537,660 @@ func buildUpstreamName(host string, b interface{}, loc interface{}) string {
 	return upstreamName
 }
 
+// buildExternalUpstream returns the directive that sets the address of the
+// backend of an ExternalName service. The name is passed to proxy_pass in a
+// variable, so NGINX resolves it using the resolver and honours its validity
+// instead of resolving it once loading the configuration.
+func buildExternalUpstream(b interface{}, loc interface{}) string {
+	backends, ok := b.([]*ingress.Backend)
+	if !ok {
+		glog.Errorf("expected an '[]*ingress.Backend' type but %T was returned", b)
+		return ""
+	}
+
+	location, ok := loc.(*ingress.Location)
+	if !ok {
+		glog.Errorf("expected a '*ingress.Location' type but %T was returned", loc)
+		return ""
+	}
+
+	for _, backend := range backends {
+		if backend.Name == location.Backend && backend.ExternalName != "" {
+			return fmt.Sprintf("set $proxy_upstream_host \"%v:%v\";", backend.ExternalName, backend.Port.String())
+		}
+	}
+
+	return ""
+}
+
+// buildUpstreamHostHeader returns the value of the Host header sent to the
+// backend. Secure backends of ExternalName services receive their own name,
+// because it must match the name used in the SNI.
+func buildUpstreamHostHeader(b interface{}, loc interface{}) string {
+	backends, ok := b.([]*ingress.Backend)
+	if !ok {
+		glog.Errorf("expected an '[]*ingress.Backend' type but %T was returned", b)
+		return bestHTTPHost
+	}
+
+	location, ok := loc.(*ingress.Location)
+	if !ok {
+		glog.Errorf("expected a '*ingress.Location' type but %T was returned", loc)
+		return bestHTTPHost
+	}
+
+	for _, backend := range backends {
+		if backend.Name == location.Backend && backend.ExternalName != "" && backend.Secure {
+			return fmt.Sprintf("\"%v\"", backend.ExternalName)
+		}
+	}
+
+	return bestHTTPHost
+}
+
+// canaryVariable returns the name of the variable that contains the
+// upstream selected by the weight of the canary of a location
+func canaryVariable(host string, location *ingress.Location) string {
+	h := fnv.New32a()
+	// #nosec
+	h.Write([]byte(fmt.Sprintf("%v %v", host, buildLocation(location))))
+	return fmt.Sprintf("$canary_%x", h.Sum32())
+}
+
+// buildCanarySplits returns the split_clients blocks that distribute
+// the requests of the locations with a canary weight between the
+// backend and the canary backend
+func buildCanarySplits(s interface{}) string {
+	servers, ok := s.([]*ingress.Server)
+	if !ok {
+		glog.Errorf("expected an '[]*ingress.Server' type but %T was returned", s)
+		return ""
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0))
+	for _, server := range servers {
+		for _, location := range server.Locations {
+			if location.CanaryBackend == "" || location.Canary.Weight <= 0 || location.Canary.Weight >= 100 {
+				continue
+			}
+
+			fmt.Fprintf(buf, `
+    split_clients "${request_id}" %v {
+        %v%% %v;
+        * %v;
+    }
+`, canaryVariable(server.Hostname, location), location.Canary.Weight, location.CanaryBackend, location.Backend)
+		}
+	}
+
+	return buf.String()
+}
+
+// buildCanaryUpstream returns the directives that select the upstream of
+// a location with a canary backend. The header takes precedence over the
+// cookie and the cookie over the weight.
+func buildCanaryUpstream(host string, loc interface{}) string {
+	location, ok := loc.(*ingress.Location)
+	if !ok {
+		glog.Errorf("expected a '*ingress.Location' type but %T was returned", loc)
+		return ""
+	}
+
+	if location.CanaryBackend == "" {
+		return ""
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0))
+	canary := location.Canary
+
+	switch {
+	case canary.Weight >= 100:
+		fmt.Fprintf(buf, "set $proxy_upstream_name \"%v\";\n", location.CanaryBackend)
+	case canary.Weight > 0:
+		fmt.Fprintf(buf, "set $proxy_upstream_name %v;\n", canaryVariable(host, location))
+	}
+
+	if canary.Cookie != "" {
+		fmt.Fprintf(buf, `if ($cookie_%v = "always") { set $proxy_upstream_name "%v"; }
+            if ($cookie_%v = "never") { set $proxy_upstream_name "%v"; }
+`, canary.Cookie, location.CanaryBackend, canary.Cookie, location.Backend)
+	}
+
+	if canary.Header != "" {
+		header := fmt.Sprintf("$http_%v", strings.Replace(strings.ToLower(canary.Header), "-", "_", -1))
+		if canary.HeaderValue != "" {
+			fmt.Fprintf(buf, `if (%v = "%v") { set $proxy_upstream_name "%v"; }
+`, header, canary.HeaderValue, location.CanaryBackend)
+		} else {
+			fmt.Fprintf(buf, `if (%v = "always") { set $proxy_upstream_name "%v"; }
+            if (%v = "never") { set $proxy_upstream_name "%v"; }
+`, header, location.CanaryBackend, header, location.Backend)
+		}
+	}
+
+	return buf.String()
+}
+
+// buildRateLimitZones returns the zones of the rate limits used in the
+// locations. The zones are defined once because they are shared by all
+// the locations of an Ingress. The clients of the allowlist are not
+// limited because their key is empty.
+func buildRateLimitZones(variable string, s interface{}) string {
+	servers, ok := s.([]*ingress.Server)
+	if !ok {
+		glog.Errorf("expected an '[]*ingress.Server' type but %T was returned", s)
+		return ""
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0))
+	processed := sets.NewString()
+	for _, server := range servers {
+		for _, location := range server.Locations {
+			rl := location.RateLimit
+			if rl.ID == "" || processed.Has(rl.ID) {
+				continue
+			}
+			processed.Insert(rl.ID)
+
+			key := variable
+			if len(rl.Allowlist) > 0 {
+				key = fmt.Sprintf("$limit_%v", rl.ID)
+				fmt.Fprintf(buf, "\n    geo $the_real_ip $allowlist_%v {\n        default 0;\n", rl.ID)
+				for _, ip := range rl.Allowlist {
+					fmt.Fprintf(buf, "        %v 1;\n", ip)
+				}
+				fmt.Fprintf(buf, "    }\n\n    map $allowlist_%v %v {\n        0 %v;\n        1 \"\";\n    }\n", rl.ID, key, variable)
+			}
+
+			if rl.Connections.Enabled() {
+				fmt.Fprintf(buf, "\n    limit_conn_zone %v zone=%v:%vm;", key, rl.Connections.Name, rl.Connections.SharedSize)
+			}
+			if rl.RPS.Enabled() {
+				fmt.Fprintf(buf, "\n    limit_req_zone %v zone=%v:%vm rate=%vr/s;", key, rl.RPS.Name, rl.RPS.SharedSize, rl.RPS.Limit)
+			}
+			if rl.RPM.Enabled() {
+				fmt.Fprintf(buf, "\n    limit_req_zone %v zone=%v:%vm rate=%vr/m;", key, rl.RPM.Name, rl.RPM.SharedSize, rl.RPM.Limit)
+			}
+			buf.WriteString("\n")
+		}
+	}
+
+	return buf.String()
+}
+
+// buildRateLimit returns the directives that apply the rate limits of a location
+func buildRateLimit(loc interface{}) string {
+	location, ok := loc.(*ingress.Location)
+	if !ok {
+		glog.Errorf("expected a '*ingress.Location' type but %T was returned", loc)
+		return ""
+	}
+
+	var limits []string
+	rl := location.RateLimit
+	if rl.Connections.Enabled() {
+		limits = append(limits, fmt.Sprintf("limit_conn %v %v;", rl.Connections.Name, rl.Connections.Limit))
+	}
+	if rl.RPS.Enabled() {
+		limits = append(limits, fmt.Sprintf("limit_req zone=%v burst=%v nodelay;", rl.RPS.Name, rl.RPS.Burst))
+	}
+	if rl.RPM.Enabled() {
+		limits = append(limits, fmt.Sprintf("limit_req zone=%v burst=%v nodelay;", rl.RPM.Name, rl.RPM.Burst))
+	}
+
+	return strings.Join(limits, "\n            ")
+}
+
+// sourceRange returns the CIDRs allowed and denied in a location, which
+// include the ones of the configuration when the Ingress does not define
+// them, and the name of the variable that indicates if the client is denied.
+func sourceRange(location *ingress.Location) ([]string, []string, string) {
+	allowlist := location.SourceRange.Allowlist
+	denylist := location.SourceRange.Denylist
+	if len(allowlist) == 0 && len(denylist) == 0 {
+		return nil, nil, ""
+	}
+
+	h := fnv.New32a()
+	// #nosec
+	h.Write([]byte(fmt.Sprintf("%v %v", strings.Join(allowlist, ","), strings.Join(denylist, ","))))
+	return allowlist, denylist, fmt.Sprintf("$source_range_denied_%x", h.Sum32())
+}
+
+// buildSourceRanges returns the geo blocks that evaluate the source ranges
+// of the locations. The client address is the one resolved in the variable
+// $the_real_ip. The most specific CIDR is used when the client belongs to
+// more than one, and the denylist takes precedence for the same CIDR.
+func buildSourceRanges(s interface{}) string {
+	servers, ok := s.([]*ingress.Server)
+	if !ok {
+		glog.Errorf("expected an '[]*ingress.Server' type but %T was returned", s)
+		return ""
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0))
+	processed := sets.NewString()
+	for _, server := range servers {
+		for _, location := range server.Locations {
+			allowlist, denylist, variable := sourceRange(location)
+			if variable == "" || processed.Has(variable) {
+				continue
+			}
+			processed.Insert(variable)
+
+			denied := sets.NewString(denylist...)
+			def := 0
+			if len(allowlist) > 0 {
+				def = 1
+			}
+			fmt.Fprintf(buf, "\n    geo $the_real_ip %v {\n        default %v;\n", variable, def)
+			for _, ip := range allowlist {
+				if !denied.Has(ip) {
+					fmt.Fprintf(buf, "        %v 0;\n", ip)
+				}
+			}
+			for _, ip := range denylist {
+				fmt.Fprintf(buf, "        %v 1;\n", ip)
+			}
+			buf.WriteString("    }\n")
+		}
+	}
+
+	return buf.String()
+}
+
+// buildSourceRangeCheck returns the directive that rejects
+// the clients denied by the source ranges of a location
+func buildSourceRangeCheck(loc interface{}) string {
+	location, ok := loc.(*ingress.Location)
+	if !ok {
+		glog.Errorf("expected a '*ingress.Location' type but %T was returned", loc)
+		return ""
+	}
+
+	_, _, variable := sourceRange(location)
+	if variable == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("if (%v) {\n                return 403;\n            }", variable)
+}
+
+// buildAffinityCookies returns a Lua table with the affinity cookies of the
+// backends of a location, indexed by the name of the backend. The path of the
+// location is used when the cookie does not define one.
+func buildAffinityCookies(b interface{}, loc interface{}) string {
+	backends, ok := b.([]*ingress.Backend)
+	if !ok {
+		glog.Errorf("expected an '[]*ingress.Backend' type but %T was returned", b)
+		return ""
+	}
+
+	location, ok := loc.(*ingress.Location)
+	if !ok {
+		glog.Errorf("expected a '*ingress.Location' type but %T was returned", loc)
+		return ""
+	}
+
+	path := location.Path
+	if location.LocationModifier != "" && location.LocationModifier != "=" {
+		// regular expressions cannot be used in the path of the cookie
+		path = slash
+	}
+
+	names := sets.NewString(location.Backend)
+	if location.CanaryBackend != "" {
+		names.Insert(location.CanaryBackend)
+	}
+
+	cookies := []string{}
+	for _, backend := range backends {
+		if !names.Has(backend.Name) || backend.SessionAffinity.Type != sessionaffinity.CookieAffinity {
+			continue
+		}
+
+		cookie := backend.SessionAffinity.Cookie
+		cookiePath := cookie.Path
+		if cookiePath == "" {
+			cookiePath = path
+		}
+		cookies = append(cookies, fmt.Sprintf(`["%v"] = { name = "%v", hash = "%v", path = "%v", expires = %v, max_age = %v, samesite = "%v", secure = %v }`,
+			backend.Name, cookie.Name, cookie.Hash, cookiePath, cookie.Expires, cookie.MaxAge, cookie.SameSite, cookie.Secure))
+	}
+
+	if len(cookies) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("{ %v }", strings.Join(cookies, ", "))
+}
+
+// buildAuthLocation returns the path of the internal location that sends
+// the authentication subrequests of a location, or an empty string if the
+// location does not use an external authentication service
+func buildAuthLocation(input interface{}) string {
+	location, ok := input.(*ingress.Location)
+	if !ok {
+		glog.Errorf("expected a '*ingress.Location' type but %T was returned", input)
+		return ""
+	}
+
+	if location.ExternalAuth.URL == "" {
+		return ""
+	}
+
+	h := fnv.New32a()
+	// #nosec
+	h.Write([]byte(buildLocation(location)))
+	return fmt.Sprintf("/_external-auth-%x", h.Sum32())
+}
+
+// buildAuthHeaders returns the directives that copy the headers of
+// the response of the authentication subrequest to the upstream request
+// sent with the module of the location
+func buildAuthHeaders(input interface{}, module string) string {
+	location, ok := input.(*ingress.Location)
+	if !ok {
+		glog.Errorf("expected a '*ingress.Location' type but %T was returned", input)
+		return ""
+	}
+
+	out := []string{}
+	for i, h := range location.ExternalAuth.ResponseHeaders {
+		hvar := strings.ToLower(h)
+		hvar = strings.NewReplacer("-", "_").Replace(hvar)
+		out = append(out, fmt.Sprintf("auth_request_set $authHeader%v $upstream_http_%v;", i, hvar))
+		out = append(out, fmt.Sprintf("%v_set_header '%v' $authHeader%v;", module, h, i))
+	}
+
+	return strings.Join(out, "\n            ")
+}
+
+// buildAuthSignURL returns the URL where the clients are redirected when the
+// authentication fails, including the original URL in the parameter rd
+func buildAuthSignURL(input interface{}) string {
+	signinURL, ok := input.(string)
+	if !ok {
+		glog.Errorf("expected a 'string' type but %T was returned", input)
+		return ""
+	}
+
+	u, _ := url.Parse(signinURL)
+	q := u.Query()
+	if len(q) == 0 {
+		return fmt.Sprintf("%v?rd=$pass_access_scheme://$best_http_host$escaped_request_uri", signinURL)
+	}
+	if q.Get("rd") != "" {
+		return signinURL
+	}
+	return fmt.Sprintf("%v&rd=$pass_access_scheme://$best_http_host$escaped_request_uri", signinURL)
+}
+
+// needsAuthCache returns true if a location caches
+// the responses of its external authentication service
+func needsAuthCache(s interface{}) bool {
+	servers, ok := s.([]*ingress.Server)
+	if !ok {
+		glog.Errorf("expected an '[]*ingress.Server' type but %T was returned", s)
+		return false
+	}
+
+	for _, server := range servers {
+		for _, location := range server.Locations {
+			if location.ExternalAuth.URL != "" && location.ExternalAuth.CacheKey != "" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// findServer returns the server of a hostname, or nil if it does not exist
+func findServer(s interface{}, hostname string) *ingress.Server {
+	servers, ok := s.([]*ingress.Server)
+	if !ok {
+		glog.Errorf("expected an '[]*ingress.Server' type but %T was returned", s)
+		return nil
+	}
+
+	for _, server := range servers {
+		if server.Hostname == hostname {
+			return server
+		}
+	}
+
+	return nil
+}
+
+// responseHeader is a header added to the responses with add_header
+type responseHeader struct {
+	Name  string
+	Value string
+}
+
+// buildResponseHeaders returns the headers added to the responses of a
+// server, with the security headers of the configuration, or of a location,
+// with the security headers of its Ingress. The headers of the add-headers
+// ConfigMap follow the security headers sorted by name, and they do not
+// replace them. NGINX only inherits the add_header directives of the server
+// when the location does not define any, so the location returns all the
+// headers, or none to use the ones of the server.
+func buildResponseHeaders(input interface{}, addHeaders map[string]string) []responseHeader {
+	var sh ingress.SecurityHeaders
+	switch v := input.(type) {
+	case config.Configuration:
+		sh = v.SecurityHeaders()
+	case *ingress.Location:
+		if v.SecurityHeaders == (ingress.SecurityHeaders{}) {
+			return nil
+		}
+		sh = v.SecurityHeaders
+	default:
+		glog.Errorf("expected a 'config.Configuration' or '*ingress.Location' type but %T was returned", input)
+		return nil
+	}
+
+	headers := []responseHeader{}
+	for _, header := range []responseHeader{
+		{"X-Frame-Options", sh.XFrameOptions},
+		{"X-Content-Type-Options", sh.XContentTypeOptions},
+		{"X-XSS-Protection", sh.XXSSProtection},
+		{"Content-Security-Policy", sh.ContentSecurityPolicy},
+		{"Strict-Transport-Security", sh.StrictTransportSecurity},
+	} {
+		if header.Value != "" {
+			headers = append(headers, header)
+		}
+	}
+
+	security := sets.NewString("x-frame-options", "x-content-type-options",
+		"x-xss-protection", "content-security-policy", "strict-transport-security")
+	names := []string{}
+	for name := range addHeaders {
+		if security.Has(strings.ToLower(name)) {
+			glog.Warningf("header %v of add-headers ignored, use the security header keys of the configuration", name)
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		headers = append(headers, responseHeader{name, addHeaders[name]})
+	}
+
+	return headers
+}
+
+// buildCompression returns the directives of a location that override the
+// compression of the responses of the configuration. Brotli is only
+// configured when its module is enabled in the configuration.
+func buildCompression(c interface{}, loc interface{}) string {
+	cfg, ok := c.(config.Configuration)
+	if !ok {
+		glog.Errorf("expected a 'config.Configuration' type but %T was returned", c)
+		return ""
+	}
+	location, ok := loc.(*ingress.Location)
+	if !ok {
+		glog.Errorf("expected a '*ingress.Location' type but %T was returned", loc)
+		return ""
+	}
+
+	out := []string{}
+	if location.Compression.Enabled != nil {
+		state := "off"
+		if *location.Compression.Enabled {
+			state = "on"
+		}
+		out = append(out, fmt.Sprintf("gzip %v;", state))
+		if cfg.EnableBrotli {
+			out = append(out, fmt.Sprintf("brotli %v;", state))
+		}
+	}
+	if location.Compression.Types != "" {
+		out = append(out, fmt.Sprintf("gzip_types %v;", location.Compression.Types))
+		if cfg.EnableBrotli {
+			out = append(out, fmt.Sprintf("brotli_types %v;", location.Compression.Types))
+		}
+	}
+
+	return strings.Join(out, "\n            ")
+}
+
+// reservedProxyHeaders are the headers sent to the upstreams by the
+// template, which cannot be replaced with the proxy-set-headers ConfigMaps
+var reservedProxyHeaders = sets.NewString("host", "upgrade", "connection", "proxy",
+	"x-real-ip", "x-forwarded-for", "x-forwarded-host", "x-forwarded-proto",
+	"x-forwarded-prefix", "x-original-uri", "x-scheme",
+	"ssl-client-cert", "ssl-client-verify", "ssl-client-subject-dn", "ssl-client-issuer-dn")
+
+// buildProxySetHeaders returns the headers sent to the upstream of a location
+// sorted by name. The headers of the Ingress of the location replace the ones
+// of the configuration with the same name. The headers copied from the
+// response of the external authentication are reserved too.
+func buildProxySetHeaders(proxySetHeaders map[string]string, loc interface{}) []responseHeader {
+	location, ok := loc.(*ingress.Location)
+	if !ok {
+		glog.Errorf("expected a '*ingress.Location' type but %T was returned", loc)
+		return nil
+	}
+
+	reserved := sets.NewString(reservedProxyHeaders.UnsortedList()...)
+	for _, name := range location.ExternalAuth.ResponseHeaders {
+		reserved.Insert(strings.ToLower(name))
+	}
+
+	merged := map[string]string{}
+	for _, source := range []map[string]string{proxySetHeaders, location.ProxySetHeaders} {
+		for name, value := range source {
+			if reserved.Has(strings.ToLower(name)) {
+				glog.Warningf("header %v of proxy-set-headers ignored, it is already sent to the upstreams", name)
+				continue
+			}
+			merged[http.CanonicalHeaderKey(name)] = value
+		}
+	}
+
+	names := []string{}
+	for name := range merged {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	headers := []responseHeader{}
+	for _, name := range names {
+		headers = append(headers, responseHeader{name, merged[name]})
+	}
+	return headers
+}
+
+// errorPage describes the named location that returns
+// the error page of a status code
+type errorPage struct {
+	Name    string
+	Code    int
+	Backend string
+	// Content is the HTML of the page as a Lua long string
+	Content string
+}
+
+// errorPageName returns the name of the location of the error page of a status code
+func errorPageName(location *ingress.Location, code int) string {
+	id := location.ErrorPages.Backend
+	if id == "" {
+		id = fmt.Sprintf("cm_%v", strings.Replace(location.ErrorPages.ConfigMap, "/", "_", -1))
+	}
+	return fmt.Sprintf("@custom_%v_%v", id, code)
+}
+
+// luaLongString returns the content of a string as a Lua long string, using
+// a level of brackets that is not included in the content
+func luaLongString(content string) string {
+	level := ""
+	// a content ending in ] would close the string before its delimiter
+	for strings.Contains(content, fmt.Sprintf("]%v]", level)) || strings.HasSuffix(content, "]"+level) {
+		level += "="
+	}
+	// the first newline of a long string is skipped
+	return fmt.Sprintf("[%v[\n%v]%v]", level, content, level)
+}
+
+// buildErrorPages returns the directives that replace the upstream
+// responses of a location with the error pages
+func buildErrorPages(loc interface{}) string {
+	location, ok := loc.(*ingress.Location)
+	if !ok {
+		glog.Errorf("expected a '*ingress.Location' type but %T was returned", loc)
+		return ""
+	}
+
+	if len(location.ErrorPages.Codes) == 0 {
+		return ""
+	}
+
+	out := []string{"proxy_intercept_errors on;"}
+	for _, code := range location.ErrorPages.Codes {
+		out = append(out, fmt.Sprintf("error_page %v = %v;", code, errorPageName(location, code)))
+	}
+
+	return strings.Join(out, "\n            ")
+}
+
+// errorPageLocations returns the error pages used by the locations of a
+// server. A page shared by more than one location is only returned once.
+func errorPageLocations(s interface{}) []errorPage {
+	server, ok := s.(*ingress.Server)
+	if !ok {
+		glog.Errorf("expected an '*ingress.Server' type but %T was returned", s)
+		return nil
+	}
+
+	pages := []errorPage{}
+	names := sets.NewString()
+	for _, location := range server.Locations {
+		for _, code := range location.ErrorPages.Codes {
+			name := errorPageName(location, code)
+			if names.Has(name) {
+				continue
+			}
+			names.Insert(name)
+
+			page := errorPage{
+				Name:    name,
+				Code:    code,
+				Backend: location.ErrorPages.Backend,
+			}
+			if page.Backend == "" {
+				page.Content = luaLongString(location.ErrorPages.Pages[code])
+			}
+			pages = append(pages, page)
+		}
+	}
+
+	return pages
+}
+
 type ingressInformation struct {
 	Namespace   string
 	Rule        string
@@ -18,11 +18,23 @@ package template
 
 import (
 	"net"
+	"reflect"
 	"strings"
 	"testing"
 
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/stolostron/management-ingress/pkg/file"
 	"github.com/stolostron/management-ingress/pkg/ingress"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/authreq"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/canary"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/compression"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/ratelimit"
 	"github.com/stolostron/management-ingress/pkg/ingress/annotations/rewrite"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/sessionaffinity"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/sourcerange"
+	"github.com/stolostron/management-ingress/pkg/ingress/controller/config"
 	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
 )
 
@@ -129,6 +141,33 @@ func TestBuildLocation(t *testing.T) {
 	}
 }
 
+func TestBuildLocationPathType(t *testing.T) {
+	testCases := map[string]struct {
+		loc      *ingress.Location
+		expected string
+	}{
+		"exact": {&ingress.Location{Path: "/foo", PathType: networking.PathTypeExact}, "= /foo"},
+		"exact with rewrite": {&ingress.Location{
+			Path:     "/foo",
+			PathType: networking.PathTypeExact,
+			Rewrite:  rewrite.Config{Target: "/"},
+		}, "= /foo"},
+		"prefix":                  {&ingress.Location{Path: "/foo/", PathType: networking.PathTypePrefix}, "/foo/"},
+		"implementation specific": {&ingress.Location{Path: "/foo", PathType: networking.PathTypeImplementationSpecific}, "/foo"},
+		"exact with location modifier": {&ingress.Location{
+			Path:             "/foo",
+			PathType:         networking.PathTypeExact,
+			LocationModifier: "~*",
+		}, "~* ^/foo"},
+	}
+
+	for k, tc := range testCases {
+		if loc := buildLocation(tc.loc); loc != tc.expected {
+			t.Errorf("%s: expected '%v' but returned %v", k, tc.expected, loc)
+		}
+	}
+}
+
 func TestBuildProxyPass(t *testing.T) {
 	defaultBackend := "upstream-name"
 	defaultHost := "example.com"
@@ -157,6 +196,465 @@ func TestBuildProxyPass(t *testing.T) {
 	}
 }
 
+func TestBuildProxyPassGRPC(t *testing.T) {
+	testCases := []struct {
+		protocol string
+		expected string
+	}{
+		{"GRPC", "grpc_pass grpc://upstream-name;"},
+		{"GRPCS", "grpc_pass grpcs://upstream-name;"},
+		{"H2C", "grpc_pass grpc://upstream-name;"},
+		{"HTTPS", "proxy_pass https://upstream-name;"},
+	}
+
+	for _, tc := range testCases {
+		backends := []*ingress.Backend{{
+			Name:            "upstream-name",
+			Secure:          tc.protocol == "HTTPS" || tc.protocol == "GRPCS",
+			BackendProtocol: tc.protocol,
+		}}
+		// the rewrite is not applied to gRPC requests
+		loc := &ingress.Location{Path: "/app", Backend: "upstream-name", UpstreamURI: "/svc"}
+		if tc.protocol == "HTTPS" {
+			loc.UpstreamURI = ""
+		}
+
+		if pp := buildProxyPass("example.com", backends, loc); pp != tc.expected {
+			t.Errorf("%v: expected %q but returned %q", tc.protocol, tc.expected, pp)
+		}
+		if grpc := usesGRPC(backends, loc); grpc != (tc.protocol != "HTTPS") {
+			t.Errorf("%v: unexpected gRPC module %v", tc.protocol, grpc)
+		}
+	}
+
+	backends := []*ingress.Backend{{Name: "upstream-name", BackendProtocol: "GRPC"}}
+	loc := &ingress.Location{Path: "/app", Backend: "upstream-name", XForwardedPrefix: true, Rewrite: rewrite.Config{Target: "/"}}
+	expected := "grpc_set_header X-Forwarded-Prefix \"/app/\";\n\t    grpc_pass grpc://upstream-name;"
+	if pp := buildProxyPass("example.com", backends, loc); pp != expected {
+		t.Errorf("expected %q but returned %q", expected, pp)
+	}
+
+	backends = []*ingress.Backend{{
+		Name:            "upstream-name",
+		Secure:          true,
+		BackendProtocol: "GRPCS",
+		ClientCACert:    resolver.AuthSSLCert{Secret: "test", PemFileName: "/test/test.crt"},
+	}}
+	loc = &ingress.Location{Path: "/", Backend: "upstream-name"}
+	if ssl := buildSSLVeify(backends, loc); ssl != "grpc_ssl_verify off;" {
+		t.Errorf("unexpected SSL verification %q", ssl)
+	}
+	if ssl := buildClientCAAuth(backends, loc); !strings.Contains(ssl, "grpc_ssl_certificate /test/test.crt;") {
+		t.Errorf("unexpected client certificate %q", ssl)
+	}
+}
+
+func TestBuildCanaryUpstream(t *testing.T) {
+	testCases := map[string]struct {
+		canary   canary.Config
+		expected []string
+	}{
+		"weight":     {canary.Config{Enabled: true, Weight: 20}, []string{"set $proxy_upstream_name $canary_"}},
+		"weight 100": {canary.Config{Enabled: true, Weight: 100}, []string{`set $proxy_upstream_name "canary";`}},
+		"header": {canary.Config{Enabled: true, Header: "X-Canary"}, []string{
+			`if ($http_x_canary = "always") { set $proxy_upstream_name "canary"; }`,
+			`if ($http_x_canary = "never") { set $proxy_upstream_name "primary"; }`,
+		}},
+		"header value": {canary.Config{Enabled: true, Header: "X-Canary", HeaderValue: "yes"}, []string{
+			`if ($http_x_canary = "yes") { set $proxy_upstream_name "canary"; }`,
+		}},
+		"cookie": {canary.Config{Enabled: true, Cookie: "beta"}, []string{
+			`if ($cookie_beta = "always") { set $proxy_upstream_name "canary"; }`,
+			`if ($cookie_beta = "never") { set $proxy_upstream_name "primary"; }`,
+		}},
+	}
+
+	for k, tc := range testCases {
+		loc := &ingress.Location{Path: "/foo", Backend: "primary", CanaryBackend: "canary", Canary: tc.canary}
+		result := buildCanaryUpstream("example.com", loc)
+		for _, expected := range tc.expected {
+			if !strings.Contains(result, expected) {
+				t.Errorf("%s: expected %q in %q", k, expected, result)
+			}
+		}
+
+		split := buildCanarySplits([]*ingress.Server{{Hostname: "example.com", Locations: []*ingress.Location{loc}}})
+		if hasSplit := strings.Contains(split, "split_clients"); hasSplit != strings.Contains(result, "$canary_") {
+			t.Errorf("%s: unexpected split_clients %q for the location %q", k, split, result)
+		}
+	}
+
+	if result := buildCanaryUpstream("example.com", &ingress.Location{Path: "/foo", Backend: "primary"}); result != "" {
+		t.Errorf("expected no directives for a location without canary but returned %q", result)
+	}
+
+	loc := &ingress.Location{Path: "/foo", Backend: "primary", CanaryBackend: "canary"}
+	if proxyPass := buildProxyPass("example.com", []*ingress.Backend{}, loc); proxyPass != "proxy_pass http://$proxy_upstream_name;" {
+		t.Errorf("expected the proxy_pass to use the selected upstream but returned %q", proxyPass)
+	}
+
+	// the path of the location is replaced with the upstream URI, as
+	// the proxy_pass with the name of an upstream does
+	backends := []*ingress.Backend{{Name: "primary", Secure: true}, {Name: "canary", Secure: true}}
+	loc.UpstreamURI = "/svc"
+	expected := "rewrite ^/foo(.*) /svc$1 break;\n\t    proxy_pass https://$proxy_upstream_name;"
+	if proxyPass := buildProxyPass("example.com", backends, loc); proxyPass != expected {
+		t.Errorf("expected %q but returned %q", expected, proxyPass)
+	}
+}
+
+func TestBuildExternalUpstream(t *testing.T) {
+	backends := []*ingress.Backend{
+		{Name: "internal", Port: intstr.FromInt(80), ClusterIP: "10.0.0.1"},
+		{Name: "external", Port: intstr.FromInt(80), ExternalName: "foo.example.com"},
+		{Name: "secure", Port: intstr.FromInt(443), ExternalName: "bar.example.com", Secure: true},
+	}
+
+	testCases := map[string]struct {
+		backend   string
+		set       string
+		proxyPass string
+		host      string
+		ssl       string
+	}{
+		"internal": {"internal", "", "proxy_pass http://internal;", "$best_http_host", ""},
+		"external": {"external", `set $proxy_upstream_host "foo.example.com:80";`, "proxy_pass http://$proxy_upstream_host;", "$best_http_host", ""},
+		"secure external": {"secure", `set $proxy_upstream_host "bar.example.com:443";`, "proxy_pass https://$proxy_upstream_host;", `"bar.example.com"`,
+			"proxy_ssl_name bar.example.com;"},
+	}
+
+	for k, tc := range testCases {
+		loc := &ingress.Location{Path: "/", Backend: tc.backend}
+		if set := buildExternalUpstream(backends, loc); set != tc.set {
+			t.Errorf("%s: expected '%v' but returned '%v'", k, tc.set, set)
+		}
+		if proxyPass := buildProxyPass("example.com", backends, loc); proxyPass != tc.proxyPass {
+			t.Errorf("%s: expected '%v' but returned '%v'", k, tc.proxyPass, proxyPass)
+		}
+		if host := buildUpstreamHostHeader(backends, loc); host != tc.host {
+			t.Errorf("%s: expected '%v' but returned '%v'", k, tc.host, host)
+		}
+		if ssl := buildSSLVeify(backends, loc); !strings.Contains(ssl, tc.ssl) {
+			t.Errorf("%s: expected '%v' in '%v'", k, tc.ssl, ssl)
+		}
+	}
+}
+
+func TestBuildRateLimit(t *testing.T) {
+	limited := &ingress.Location{Path: "/foo", RateLimit: ratelimit.Config{
+		ID:          "default_foo",
+		Connections: ratelimit.Zone{Name: "default_foo_conn", Limit: 5, SharedSize: 5},
+		RPS:         ratelimit.Zone{Name: "default_foo_rps", Limit: 10, Burst: 50, SharedSize: 5},
+	}}
+	allowlisted := &ingress.Location{Path: "/bar", RateLimit: ratelimit.Config{
+		ID:        "default_bar",
+		RPM:       ratelimit.Zone{Name: "default_bar_rpm", Limit: 60, Burst: 300, SharedSize: 5},
+		Allowlist: []string{"10.0.0.0/8"},
+	}}
+	// the locations of the same Ingress share the zones
+	shared := &ingress.Location{Path: "/baz", RateLimit: limited.RateLimit}
+	servers := []*ingress.Server{{
+		Hostname:  "example.com",
+		Locations: []*ingress.Location{limited, allowlisted, shared, {Path: "/"}},
+	}}
+
+	zones := buildRateLimitZones("$binary_remote_addr", servers)
+	expected := []string{
+		"limit_conn_zone $binary_remote_addr zone=default_foo_conn:5m;",
+		"limit_req_zone $binary_remote_addr zone=default_foo_rps:5m rate=10r/s;",
+		"geo $the_real_ip $allowlist_default_bar {",
+		"10.0.0.0/8 1;",
+		"map $allowlist_default_bar $limit_default_bar {",
+		"0 $binary_remote_addr;",
+		"limit_req_zone $limit_default_bar zone=default_bar_rpm:5m rate=60r/m;",
+	}
+	for _, e := range expected {
+		if !strings.Contains(zones, e) {
+			t.Errorf("expected %q in %q", e, zones)
+		}
+	}
+	if strings.Count(zones, "zone=default_foo_rps") != 1 {
+		t.Errorf("expected a single definition of the zone default_foo_rps in %q", zones)
+	}
+
+	if limits := buildRateLimit(limited); limits != "limit_conn default_foo_conn 5;\n            limit_req zone=default_foo_rps burst=50 nodelay;" {
+		t.Errorf("unexpected limits %q", limits)
+	}
+	if limits := buildRateLimit(&ingress.Location{Path: "/"}); limits != "" {
+		t.Errorf("expected no limits but returned %q", limits)
+	}
+}
+
+func TestBuildSourceRanges(t *testing.T) {
+	restricted := &ingress.Location{Path: "/foo", SourceRange: sourcerange.Config{
+		Allowlist: []string{"10.0.0.0/8", "10.1.0.0/16"},
+		Denylist:  []string{"10.1.0.0/16"},
+	}}
+	// the controller sets the source ranges of the configuration
+	defaulted := &ingress.Location{Path: "/", SourceRange: sourcerange.Config{Denylist: []string{"192.168.0.0/16"}}}
+	servers := []*ingress.Server{{
+		Hostname:  "example.com",
+		Locations: []*ingress.Location{restricted, defaulted, {Path: "/bar"}},
+	}}
+
+	_, _, restrictedVar := sourceRange(restricted)
+	_, _, defaultedVar := sourceRange(defaulted)
+	if restrictedVar == defaultedVar {
+		t.Fatalf("expected different variables for different source ranges")
+	}
+
+	geos := buildSourceRanges(servers)
+	expected := []string{
+		"geo $the_real_ip " + restrictedVar + " {\n        default 1;\n        10.0.0.0/8 0;\n        10.1.0.0/16 1;\n    }",
+		"geo $the_real_ip " + defaultedVar + " {\n        default 0;\n        192.168.0.0/16 1;\n    }",
+	}
+	for _, e := range expected {
+		if !strings.Contains(geos, e) {
+			t.Errorf("expected %q in %q", e, geos)
+		}
+	}
+	if strings.Count(geos, "geo ") != 2 {
+		t.Errorf("expected a geo block for each source range in %q", geos)
+	}
+
+	if check := buildSourceRangeCheck(restricted); !strings.Contains(check, "if ("+restrictedVar+")") || !strings.Contains(check, "return 403;") {
+		t.Errorf("unexpected source range check %q", check)
+	}
+	if check := buildSourceRangeCheck(&ingress.Location{Path: "/bar"}); check != "" {
+		t.Errorf("expected no source range check but returned %q", check)
+	}
+}
+
+func TestBuildExternalAuth(t *testing.T) {
+	foo := &ingress.Location{Path: "/foo", ExternalAuth: authreq.Config{
+		URL:             "http://auth.default.svc/verify",
+		Host:            "auth.default.svc",
+		ResponseHeaders: []string{"X-Auth-User", "X-Auth-Groups"},
+	}}
+	bar := &ingress.Location{Path: "/bar", ExternalAuth: foo.ExternalAuth}
+	none := &ingress.Location{Path: "/"}
+
+	fooPath := buildAuthLocation(foo)
+	if !strings.HasPrefix(fooPath, "/_external-auth-") {
+		t.Errorf("unexpected auth location %q", fooPath)
+	}
+	if fooPath == buildAuthLocation(bar) {
+		t.Errorf("expected different auth locations for %v and %v", foo.Path, bar.Path)
+	}
+	if path := buildAuthLocation(none); path != "" {
+		t.Errorf("expected no auth location but returned %q", path)
+	}
+
+	expected := "auth_request_set $authHeader0 $upstream_http_x_auth_user;\n" +
+		"            proxy_set_header 'X-Auth-User' $authHeader0;\n" +
+		"            auth_request_set $authHeader1 $upstream_http_x_auth_groups;\n" +
+		"            proxy_set_header 'X-Auth-Groups' $authHeader1;"
+	if headers := buildAuthHeaders(foo, "proxy"); headers != expected {
+		t.Errorf("expected %q but returned %q", expected, headers)
+	}
+	if headers := buildAuthHeaders(foo, "grpc"); !strings.Contains(headers, "grpc_set_header 'X-Auth-User' $authHeader0;") {
+		t.Errorf("expected the gRPC module in the auth headers but returned %q", headers)
+	}
+
+	signin := map[string]string{
+		"https://auth/signin":         "https://auth/signin?rd=$pass_access_scheme://$best_http_host$escaped_request_uri",
+		"https://auth/signin?a=b":     "https://auth/signin?a=b&rd=$pass_access_scheme://$best_http_host$escaped_request_uri",
+		"https://auth/signin?rd=/foo": "https://auth/signin?rd=/foo",
+	}
+	for u, e := range signin {
+		if r := buildAuthSignURL(u); r != e {
+			t.Errorf("expected %q but returned %q", e, r)
+		}
+	}
+
+	servers := []*ingress.Server{{Hostname: "example.com", Locations: []*ingress.Location{foo, none}}}
+	if needsAuthCache(servers) {
+		t.Errorf("expected no auth cache without a cache key")
+	}
+	foo.ExternalAuth.CacheKey = "$http_authorization"
+	if !needsAuthCache(servers) {
+		t.Errorf("expected an auth cache with a cache key")
+	}
+}
+
+func TestBuildAffinityCookies(t *testing.T) {
+	backends := []*ingress.Backend{
+		{Name: "default-foo-80", SessionAffinity: sessionaffinity.Config{
+			Type:   sessionaffinity.CookieAffinity,
+			Cookie: sessionaffinity.CookieConfig{Name: "route", Hash: "md5", MaxAge: 3600},
+		}},
+		{Name: "default-bar-80", SessionAffinity: sessionaffinity.Config{
+			Type:   sessionaffinity.CookieAffinity,
+			Cookie: sessionaffinity.CookieConfig{Name: "canary", Hash: "sha1", Path: "/", SameSite: "Lax", Secure: true},
+		}},
+		{Name: "default-baz-80"},
+	}
+
+	testCases := []struct {
+		name     string
+		location *ingress.Location
+		expected string
+	}{
+		{"location path", &ingress.Location{Path: "/foo", Backend: "default-foo-80"},
+			`{ ["default-foo-80"] = { name = "route", hash = "md5", path = "/foo", expires = 0, max_age = 3600, samesite = "", secure = false } }`},
+		{"regular expression", &ingress.Location{Path: "/foo/.*", LocationModifier: "~*", Backend: "default-foo-80"},
+			`{ ["default-foo-80"] = { name = "route", hash = "md5", path = "/", expires = 0, max_age = 3600, samesite = "", secure = false } }`},
+		{"canary", &ingress.Location{Path: "/foo", Backend: "default-baz-80", CanaryBackend: "default-bar-80"},
+			`{ ["default-bar-80"] = { name = "canary", hash = "sha1", path = "/", expires = 0, max_age = 0, samesite = "Lax", secure = true } }`},
+		{"without affinity", &ingress.Location{Path: "/baz", Backend: "default-baz-80"}, ""},
+	}
+
+	for _, tc := range testCases {
+		if cookies := buildAffinityCookies(backends, tc.location); cookies != tc.expected {
+			t.Errorf("%v: expected %q but returned %q", tc.name, tc.expected, cookies)
+		}
+	}
+}
+
+func TestBuildErrorPages(t *testing.T) {
+	service := &ingress.Location{Path: "/foo", ErrorPages: ingress.ErrorPages{
+		Codes:   []int{404, 503},
+		Backend: "default-errors-80",
+	}}
+	configmap := &ingress.Location{Path: "/bar", ErrorPages: ingress.ErrorPages{
+		Codes:     []int{404},
+		ConfigMap: "default/pages",
+		Pages:     map[int]string{404: "<p>]]</p>"},
+	}}
+	// the locations with the same error pages share the named locations
+	shared := &ingress.Location{Path: "/baz", ErrorPages: service.ErrorPages}
+	server := &ingress.Server{
+		Hostname:  "example.com",
+		Locations: []*ingress.Location{service, configmap, shared, {Path: "/"}},
+	}
+
+	expected := "proxy_intercept_errors on;\n            error_page 404 = @custom_default-errors-80_404;\n            error_page 503 = @custom_default-errors-80_503;"
+	if pages := buildErrorPages(service); pages != expected {
+		t.Errorf("expected %q but returned %q", expected, pages)
+	}
+	if pages := buildErrorPages(&ingress.Location{Path: "/"}); pages != "" {
+		t.Errorf("expected no error pages but returned %q", pages)
+	}
+
+	locations := errorPageLocations(server)
+	expectedLocations := []errorPage{
+		{Name: "@custom_default-errors-80_404", Code: 404, Backend: "default-errors-80"},
+		{Name: "@custom_default-errors-80_503", Code: 503, Backend: "default-errors-80"},
+		{Name: "@custom_cm_default_pages_404", Code: 404, Content: "[=[\n<p>]]</p>]=]"},
+	}
+	if !reflect.DeepEqual(locations, expectedLocations) {
+		t.Errorf("expected %+v but returned %+v", expectedLocations, locations)
+	}
+}
+
+func TestBuildResponseHeaders(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.HSTSPreload = true
+	addHeaders := map[string]string{"X-Foo": "bar", "Server-Id": "1", "x-frame-options": "DENY"}
+
+	expected := []responseHeader{
+		{"X-Frame-Options", "SAMEORIGIN"},
+		{"X-Content-Type-Options", "nosniff"},
+		{"X-XSS-Protection", "1; mode=block"},
+		{"Strict-Transport-Security", "max-age=63072000; includeSubDomains; preload"},
+		{"Server-Id", "1"},
+		{"X-Foo", "bar"},
+	}
+	if headers := buildResponseHeaders(cfg, addHeaders); !reflect.DeepEqual(headers, expected) {
+		t.Errorf("expected %+v but returned %+v", expected, headers)
+	}
+
+	location := &ingress.Location{Path: "/", SecurityHeaders: ingress.SecurityHeaders{
+		ContentSecurityPolicy: "frame-ancestors 'self' https://console.example.com",
+	}}
+	expected = []responseHeader{
+		{"Content-Security-Policy", "frame-ancestors 'self' https://console.example.com"},
+	}
+	if headers := buildResponseHeaders(location, nil); !reflect.DeepEqual(headers, expected) {
+		t.Errorf("expected %+v but returned %+v", expected, headers)
+	}
+
+	// the locations without security headers use the headers of the server
+	if headers := buildResponseHeaders(&ingress.Location{Path: "/"}, addHeaders); headers != nil {
+		t.Errorf("expected no headers but returned %+v", headers)
+	}
+}
+
+func TestBuildCompression(t *testing.T) {
+	enabled := true
+	disabled := false
+	cfg := config.NewDefault()
+	brotli := config.NewDefault()
+	brotli.EnableBrotli = true
+
+	testCases := []struct {
+		name     string
+		cfg      config.Configuration
+		config   compression.Config
+		expected string
+	}{
+		{"configuration", cfg, compression.Config{}, ""},
+		{"disabled", cfg, compression.Config{Enabled: &disabled}, "gzip off;"},
+		{"types", cfg, compression.Config{Types: "text/css"}, "gzip_types text/css;"},
+		{"brotli", brotli, compression.Config{Enabled: &enabled, Types: "text/css"},
+			"gzip on;\n            brotli on;\n            gzip_types text/css;\n            brotli_types text/css;"},
+	}
+
+	for _, tc := range testCases {
+		location := &ingress.Location{Path: "/", Compression: tc.config}
+		if directives := buildCompression(tc.cfg, location); directives != tc.expected {
+			t.Errorf("%v: expected %q but returned %q", tc.name, tc.expected, directives)
+		}
+	}
+}
+
+func TestBuildProxySetHeaders(t *testing.T) {
+	global := map[string]string{"x-tenant": "global", "X-Cluster": "hub", "Host": "example.com"}
+	location := &ingress.Location{Path: "/", ProxySetHeaders: map[string]string{"X-Tenant": "app", "X-Team": "a"}}
+
+	expected := []responseHeader{
+		{"X-Cluster", "hub"},
+		{"X-Team", "a"},
+		{"X-Tenant", "app"},
+	}
+	if headers := buildProxySetHeaders(global, location); !reflect.DeepEqual(headers, expected) {
+		t.Errorf("expected %+v but returned %+v", expected, headers)
+	}
+
+	if headers := buildProxySetHeaders(nil, &ingress.Location{Path: "/"}); len(headers) != 0 {
+		t.Errorf("expected no headers but returned %+v", headers)
+	}
+
+	// the client certificate and the external authentication headers are reserved
+	global = map[string]string{"SSL-Client-Verify": "SUCCESS", "X-Auth-User": "admin", "X-Cluster": "hub"}
+	location = &ingress.Location{Path: "/", ExternalAuth: authreq.Config{ResponseHeaders: []string{"X-Auth-User"}}}
+	expected = []responseHeader{{"X-Cluster", "hub"}}
+	if headers := buildProxySetHeaders(global, location); !reflect.DeepEqual(headers, expected) {
+		t.Errorf("expected %+v but returned %+v", expected, headers)
+	}
+	if headers := buildProxySetHeaders(global, &ingress.Location{Path: "/"}); len(headers) != 2 {
+		t.Errorf("expected the auth headers of other locations not to be reserved but returned %+v", headers)
+	}
+}
+
+func TestLuaLongString(t *testing.T) {
+	testCases := []struct {
+		content  string
+		expected string
+	}{
+		{"<p>error</p>", "[[\n<p>error</p>]]"},
+		{"<p>]]</p>", "[=[\n<p>]]</p>]=]"},
+		{`["error"]`, "[=[\n[\"error\"]]=]"},
+		{`[["error"]]=`, "[==[\n[[\"error\"]]=]==]"},
+	}
+
+	for _, tc := range testCases {
+		if s := luaLongString(tc.content); s != tc.expected {
+			t.Errorf("expected %q but returned %q", tc.expected, s)
+		}
+	}
+}
+
 func TestBuildClientBodyBufferSize(t *testing.T) {
 	a := isValidClientBodyBufferSize("1000")
 	if a != true {
@@ -273,3 +771,126 @@ func TestBuildClientCAAuth(t *testing.T) {
 		t.Errorf("Expected '%v' but returned '%v'", validBackend, sslBackend)
 	}
 }
+
+func TestReadyEndpoints(t *testing.T) {
+	backend := &ingress.Backend{
+		Name: "upstream-name",
+		Endpoints: []ingress.Endpoint{
+			{Address: "10.1.0.1", Port: "8080", Ready: true},
+			{Address: "10.1.0.2", Port: "8080", Ready: false},
+			{Address: "10.1.0.3", Port: "8080", Ready: true},
+		},
+	}
+
+	eps := readyEndpoints(backend)
+	if len(eps) != 2 {
+		t.Fatalf("Expected 2 endpoints but returned %v", len(eps))
+	}
+	for _, ep := range eps {
+		if !ep.Ready {
+			t.Errorf("Expected only ready endpoints but returned %v", ep)
+		}
+	}
+
+	if eps := readyEndpoints(&ingress.Backend{}); len(eps) != 0 {
+		t.Errorf("Expected no endpoints but returned %v", eps)
+	}
+
+	if eps := readyEndpoints(nil); len(eps) != 0 {
+		t.Errorf("Expected no endpoints but returned %v", eps)
+	}
+}
+
+// renderTemplate returns the NGINX configuration of the template of the image
+func renderTemplate(t *testing.T, servers []*ingress.Server, backends []*ingress.Backend) string {
+	tmpl, err := NewTemplate("../../../../rootfs/opt/ibm/router/nginx/template/nginx.tmpl", &file.DefaultFs{})
+	if err != nil {
+		t.Fatalf("unexpected error reading the template: %v", err)
+	}
+
+	out, err := tmpl.Write(config.TemplateConfig{
+		Cfg:         config.NewDefault(),
+		Backends:    backends,
+		Servers:     servers,
+		ListenPorts: &config.ListenPorts{HTTP: 80, HTTPS: 443, Status: 18080},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error rendering the template: %v", err)
+	}
+	return string(out)
+}
+
+func TestTemplateGRPCHeaders(t *testing.T) {
+	backend := &ingress.Backend{
+		Name:            "default-grpc-50051",
+		Secure:          true,
+		BackendProtocol: "GRPCS",
+		ExternalName:    "grpc.example.com",
+	}
+	loc := &ingress.Location{
+		Path:             "/app",
+		Backend:          backend.Name,
+		XForwardedPrefix: true,
+		Rewrite:          rewrite.Config{Target: "/"},
+		ExternalAuth: authreq.Config{
+			URL:             "http://auth.default.svc/verify",
+			Host:            "auth.default.svc",
+			ResponseHeaders: []string{"X-Auth-User"},
+		},
+	}
+	loc.CertificateAuth.CAFileName = "/etc/ingress-controller/ssl/ca-default-ca.pem"
+	loc.CertificateAuth.PassCertToUpstream = true
+
+	out := renderTemplate(t, []*ingress.Server{{Hostname: "example.com", Locations: []*ingress.Location{loc}}}, []*ingress.Backend{backend})
+
+	expected := []string{
+		"grpc_set_header 'X-Auth-User' $authHeader0;",
+		"grpc_set_header ssl-client-verify      $ssl_client_verify;",
+		`grpc_set_header X-Forwarded-Prefix "/app/";`,
+		`grpc_set_header Host                    "grpc.example.com";`,
+		"grpc_pass grpcs://$proxy_upstream_host;",
+	}
+	for _, e := range expected {
+		if !strings.Contains(out, e) {
+			t.Errorf("expected %q in the gRPC location", e)
+		}
+	}
+	for _, u := range []string{"proxy_set_header 'X-Auth-User'", "proxy_set_header ssl-client", "proxy_set_header X-Forwarded-Prefix"} {
+		if strings.Contains(out, u) {
+			t.Errorf("unexpected %q in the gRPC location", u)
+		}
+	}
+}
+
+func TestTemplateServerCertificateAuth(t *testing.T) {
+	auth := &ingress.Server{Hostname: "auth.example.com", Locations: []*ingress.Location{{Path: "/"}}}
+	auth.CertificateAuth.CAFileName = "/etc/ingress-controller/ssl/ca-default-ca.pem"
+	auth.CertificateAuth.PemSHA = "0123456789abcdef"
+	auth.CertificateAuth.VerifyClient = "optional"
+	auth.CertificateAuth.ValidationDepth = 2
+	other := &ingress.Server{Hostname: "other.example.com", Locations: []*ingress.Location{{Path: "/"}}}
+
+	out := renderTemplate(t, []*ingress.Server{auth, other}, nil)
+
+	expected := []string{
+		"# PEM sha: 0123456789abcdef",
+		"ssl_client_certificate                  /etc/ingress-controller/ssl/ca-default-ca.pem;",
+		"ssl_verify_client                       optional;",
+		"ssl_verify_depth                        2;",
+	}
+	for _, e := range expected {
+		if strings.Count(out, e) != 1 {
+			t.Errorf("expected %q once in the server of the client certificate authentication", e)
+		}
+	}
+
+	// the directives belong to the server block of the host
+	start := strings.Index(out, "server_name auth.example.com")
+	end := strings.Index(out, "server_name other.example.com")
+	if start < 0 || end < 0 {
+		t.Fatalf("expected the servers of both hosts")
+	}
+	if i := strings.Index(out, "ssl_verify_client"); i < start || i > end {
+		t.Errorf("expected ssl_verify_client in the server of host auth.example.com")
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package metric
+
+import (
+	"net/http"
+	"time"
+
+	networking "k8s.io/api/networking/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress"
+)
+
+// DummyCollector noop implementation of a metric collector
+type DummyCollector struct{}
+
+// NewDummyCollector creates a Collector that discards all the metrics
+func NewDummyCollector() Collector {
+	return DummyCollector{}
+}
+
+// ObserveSyncDuration ...
+func (dc DummyCollector) ObserveSyncDuration(time.Duration) {}
+
+// ObserveTemplateRender ...
+func (dc DummyCollector) ObserveTemplateRender(time.Duration) {}
+
+// IncReloadCount ...
+func (dc DummyCollector) IncReloadCount() {}
+
+// IncReloadErrorCount ...
+func (dc DummyCollector) IncReloadErrorCount() {}
+
+// SetConfigSize ...
+func (dc DummyCollector) SetConfigSize(int) {}
+
+// SetConfiguration ...
+func (dc DummyCollector) SetConfiguration(*ingress.Configuration) {}
+
+// SetLeader ...
+func (dc DummyCollector) SetLeader(bool) {}
+
+// SetSSLExpireTime ...
+func (dc DummyCollector) SetSSLExpireTime([]*ingress.SSLCert) {}
+
+// SetExcludedIngresses ...
+func (dc DummyCollector) SetExcludedIngresses([]*networking.Ingress) {}
+
+// Handle ...
+func (dc DummyCollector) Handle(string, http.Handler) {}
+
+// Start ...
+func (dc DummyCollector) Start() {}
+
+// Stop ...
+func (dc DummyCollector) Stop() {}
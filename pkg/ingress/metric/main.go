@@ -0,0 +1,252 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package metric
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	networking "k8s.io/api/networking/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress"
+)
+
+const (
+	// PrometheusNamespace default metric namespace
+	PrometheusNamespace = "management_ingress"
+
+	// MetricsPath is the path where the metrics are exposed
+	MetricsPath = "/metrics"
+)
+
+// Collector defines the interface for a metric collector
+type Collector interface {
+	// ObserveSyncDuration records the time spent in one sync of the ingress configuration
+	ObserveSyncDuration(time.Duration)
+	// ObserveTemplateRender records the time spent rendering the NGINX template
+	ObserveTemplateRender(time.Duration)
+	// IncReloadCount increments the number of successful reloads
+	IncReloadCount()
+	// IncReloadErrorCount increments the number of failed reloads
+	IncReloadErrorCount()
+	// SetConfigSize sets the size in bytes of the last rendered configuration
+	SetConfigSize(int)
+	// SetConfiguration sets the number of backends and servers of the running configuration
+	SetConfiguration(*ingress.Configuration)
+	// SetLeader sets if this instance is the status update leader
+	SetLeader(bool)
+	// SetSSLExpireTime sets the expiration time of the SSL certificates in use
+	SetSSLExpireTime([]*ingress.SSLCert)
+	// SetExcludedIngresses sets the Ingresses excluded from the configuration
+	// because they generate an invalid NGINX configuration
+	SetExcludedIngresses([]*networking.Ingress)
+
+	// Handle registers an additional handler in the HTTP server of the metrics
+	Handle(pattern string, handler http.Handler)
+
+	// Start exposes the metrics using a HTTP server
+	Start()
+	// Stop shuts down the HTTP server
+	Stop()
+}
+
+type collector struct {
+	port int
+
+	registry *prometheus.Registry
+	mux      *http.ServeMux
+	server   *http.Server
+
+	syncDuration   prometheus.Histogram
+	renderDuration prometheus.Histogram
+	reloads        *prometheus.CounterVec
+	configSize     prometheus.Gauge
+	backends       prometheus.Gauge
+	servers        prometheus.Gauge
+	leader         prometheus.Gauge
+	sslExpireTime  *prometheus.GaugeVec
+	excluded       *prometheus.GaugeVec
+}
+
+// NewCollector creates a new metric collector that exposes the controller
+// and NGINX metrics in the port passed as argument
+func NewCollector(port int, ingressClass string, nginxStatusURL string) Collector {
+	constLabels := prometheus.Labels{
+		"controller_class": ingressClass,
+	}
+
+	c := &collector{
+		port:     port,
+		registry: prometheus.NewRegistry(),
+
+		syncDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace:   PrometheusNamespace,
+			Name:        "sync_duration_seconds",
+			Help:        "Time spent synchronizing the ingress configuration",
+			ConstLabels: constLabels,
+			Buckets:     prometheus.DefBuckets,
+		}),
+		renderDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace:   PrometheusNamespace,
+			Name:        "template_render_duration_seconds",
+			Help:        "Time spent rendering the NGINX configuration template",
+			ConstLabels: constLabels,
+			Buckets:     prometheus.DefBuckets,
+		}),
+		reloads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   PrometheusNamespace,
+			Name:        "config_reloads_total",
+			Help:        "Number of NGINX configuration reloads by result",
+			ConstLabels: constLabels,
+		}, []string{"result"}),
+		configSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   PrometheusNamespace,
+			Name:        "config_size_bytes",
+			Help:        "Size of the last rendered NGINX configuration",
+			ConstLabels: constLabels,
+		}),
+		backends: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   PrometheusNamespace,
+			Name:        "backends",
+			Help:        "Number of backends in the running configuration",
+			ConstLabels: constLabels,
+		}),
+		servers: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   PrometheusNamespace,
+			Name:        "servers",
+			Help:        "Number of servers in the running configuration",
+			ConstLabels: constLabels,
+		}),
+		leader: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   PrometheusNamespace,
+			Name:        "leader_election_status",
+			Help:        "Indicates if this instance is the status update leader (1) or not (0)",
+			ConstLabels: constLabels,
+		}),
+		sslExpireTime: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   PrometheusNamespace,
+			Name:        "ssl_expire_time_seconds",
+			Help:        "Number of seconds since 1970 to the SSL certificate expire",
+			ConstLabels: constLabels,
+		}, []string{"namespace", "secret"}),
+		excluded: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   PrometheusNamespace,
+			Name:        "excluded_ingresses",
+			Help:        "Ingresses excluded from the configuration because they generate an invalid NGINX configuration",
+			ConstLabels: constLabels,
+		}, []string{"namespace", "ingress"}),
+	}
+
+	// initialize both results so they are reported before the first reload
+	c.reloads.WithLabelValues("success")
+	c.reloads.WithLabelValues("failure")
+
+	c.registry.MustRegister(
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		prometheus.NewGoCollector(),
+		c.syncDuration,
+		c.renderDuration,
+		c.reloads,
+		c.configSize,
+		c.backends,
+		c.servers,
+		c.leader,
+		c.sslExpireTime,
+		c.excluded,
+	)
+
+	if nginxStatusURL != "" {
+		c.registry.MustRegister(newNGINXStatusCollector(nginxStatusURL, constLabels))
+	}
+
+	c.mux = http.NewServeMux()
+	c.mux.Handle(MetricsPath, promhttp.HandlerFor(c.registry, promhttp.HandlerOpts{}))
+	c.server = &http.Server{
+		Addr:    fmt.Sprintf(":%v", port),
+		Handler: c.mux,
+	}
+
+	return c
+}
+
+func (c *collector) ObserveSyncDuration(d time.Duration) {
+	c.syncDuration.Observe(d.Seconds())
+}
+
+func (c *collector) ObserveTemplateRender(d time.Duration) {
+	c.renderDuration.Observe(d.Seconds())
+}
+
+func (c *collector) IncReloadCount() {
+	c.reloads.WithLabelValues("success").Inc()
+}
+
+func (c *collector) IncReloadErrorCount() {
+	c.reloads.WithLabelValues("failure").Inc()
+}
+
+func (c *collector) SetConfigSize(size int) {
+	c.configSize.Set(float64(size))
+}
+
+func (c *collector) SetConfiguration(cfg *ingress.Configuration) {
+	if cfg == nil {
+		return
+	}
+	c.backends.Set(float64(len(cfg.Backends)))
+	c.servers.Set(float64(len(cfg.Servers)))
+}
+
+func (c *collector) SetLeader(isLeader bool) {
+	if isLeader {
+		c.leader.Set(1)
+		return
+	}
+	c.leader.Set(0)
+}
+
+func (c *collector) SetSSLExpireTime(certs []*ingress.SSLCert) {
+	// remove certificates that are not referenced anymore
+	c.sslExpireTime.Reset()
+
+	for _, cert := range certs {
+		if cert == nil || cert.ExpireTime.IsZero() {
+			continue
+		}
+		c.sslExpireTime.WithLabelValues(cert.Namespace, cert.Name).Set(float64(cert.ExpireTime.Unix()))
+	}
+}
+
+func (c *collector) SetExcludedIngresses(ings []*networking.Ingress) {
+	c.excluded.Reset()
+
+	for _, ing := range ings {
+		c.excluded.WithLabelValues(ing.Namespace, ing.Name).Set(1)
+	}
+}
+
+func (c *collector) Handle(pattern string, handler http.Handler) {
+	c.mux.Handle(pattern, handler)
+}
+
+func (c *collector) Start() {
+	glog.Infof("exposing metrics in port %v", c.port)
+	if err := c.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		glog.Errorf("unexpected error exposing metrics: %v", err)
+	}
+}
+
+func (c *collector) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.server.Shutdown(ctx); err != nil {
+		glog.Warningf("unexpected error stopping metrics server: %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package metric
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stolostron/management-ingress/pkg/ingress"
+)
+
+func TestCollector(t *testing.T) {
+	c := NewCollector(0, "test", "").(*collector)
+
+	c.IncReloadCount()
+	c.IncReloadCount()
+	c.IncReloadErrorCount()
+	c.SetConfigSize(1024)
+	c.SetConfiguration(&ingress.Configuration{
+		Backends: []*ingress.Backend{{Name: "a"}, {Name: "b"}},
+		Servers:  []*ingress.Server{{Hostname: "_"}},
+	})
+	c.SetLeader(true)
+
+	expected := `
+		# HELP management_ingress_backends Number of backends in the running configuration
+		# TYPE management_ingress_backends gauge
+		management_ingress_backends{controller_class="test"} 2
+		# HELP management_ingress_config_reloads_total Number of NGINX configuration reloads by result
+		# TYPE management_ingress_config_reloads_total counter
+		management_ingress_config_reloads_total{controller_class="test",result="failure"} 1
+		management_ingress_config_reloads_total{controller_class="test",result="success"} 2
+		# HELP management_ingress_config_size_bytes Size of the last rendered NGINX configuration
+		# TYPE management_ingress_config_size_bytes gauge
+		management_ingress_config_size_bytes{controller_class="test"} 1024
+		# HELP management_ingress_leader_election_status Indicates if this instance is the status update leader (1) or not (0)
+		# TYPE management_ingress_leader_election_status gauge
+		management_ingress_leader_election_status{controller_class="test"} 1
+		# HELP management_ingress_servers Number of servers in the running configuration
+		# TYPE management_ingress_servers gauge
+		management_ingress_servers{controller_class="test"} 1
+	`
+	err := testutil.GatherAndCompare(c.registry, strings.NewReader(expected),
+		"management_ingress_backends", "management_ingress_config_reloads_total",
+		"management_ingress_config_size_bytes", "management_ingress_leader_election_status",
+		"management_ingress_servers")
+	if err != nil {
+		t.Errorf("unexpected error comparing metrics: %v", err)
+	}
+}
+
+func TestSetSSLExpireTime(t *testing.T) {
+	c := NewCollector(0, "test", "").(*collector)
+
+	expire := time.Unix(1700000000, 0)
+	c.SetSSLExpireTime([]*ingress.SSLCert{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "old-cert"}, ExpireTime: expire},
+	})
+	c.SetSSLExpireTime([]*ingress.SSLCert{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "tls-cert"}, ExpireTime: expire},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "ca-only"}},
+		nil,
+	})
+
+	expected := `
+		# HELP management_ingress_ssl_expire_time_seconds Number of seconds since 1970 to the SSL certificate expire
+		# TYPE management_ingress_ssl_expire_time_seconds gauge
+		management_ingress_ssl_expire_time_seconds{controller_class="test",namespace="default",secret="tls-cert"} 1.7e+09
+	`
+	err := testutil.CollectAndCompare(c.sslExpireTime, strings.NewReader(expected))
+	if err != nil {
+		t.Errorf("unexpected error comparing metrics: %v", err)
+	}
+}
+
+func TestSetExcludedIngresses(t *testing.T) {
+	c := NewCollector(0, "test", "").(*collector)
+
+	c.SetExcludedIngresses([]*networking.Ingress{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "fixed"}},
+	})
+	c.SetExcludedIngresses([]*networking.Ingress{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "broken"}},
+	})
+
+	expected := `
+		# HELP management_ingress_excluded_ingresses Ingresses excluded from the configuration because they generate an invalid NGINX configuration
+		# TYPE management_ingress_excluded_ingresses gauge
+		management_ingress_excluded_ingresses{controller_class="test",ingress="broken",namespace="default"} 1
+	`
+	err := testutil.CollectAndCompare(c.excluded, strings.NewReader(expected))
+	if err != nil {
+		t.Errorf("unexpected error comparing metrics: %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package metric
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	ac      = regexp.MustCompile(`Active connections: (\d+)`)
+	sahr    = regexp.MustCompile(`(\d+)\s(\d+)\s(\d+)`)
+	reading = regexp.MustCompile(`Reading: (\d+)`)
+	writing = regexp.MustCompile(`Writing: (\d+)`)
+	waiting = regexp.MustCompile(`Waiting: (\d+)`)
+)
+
+// nginxStatus contains the information returned by the NGINX stub_status module
+type nginxStatus struct {
+	// Active total number of active connections
+	Active int
+	// Accepted total number of accepted client connections
+	Accepted int
+	// Handled total number of handled connections
+	Handled int
+	// Requests total number of client requests
+	Requests int
+	// Reading current number of connections where nginx is reading the request header
+	Reading int
+	// Writing current number of connections where nginx is writing the response back to the client
+	Writing int
+	// Waiting current number of idle client connections waiting for a request
+	Waiting int
+}
+
+// nginxStatusCollector scrapes the NGINX stub_status page each time
+// the metrics are collected
+type nginxStatusCollector struct {
+	url    string
+	client *http.Client
+
+	connectionsTotal *prometheus.Desc
+	requestsTotal    *prometheus.Desc
+	connections      *prometheus.Desc
+}
+
+func newNGINXStatusCollector(url string, constLabels prometheus.Labels) *nginxStatusCollector {
+	return &nginxStatusCollector{
+		url:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+
+		connectionsTotal: prometheus.NewDesc(
+			prometheus.BuildFQName(PrometheusNamespace, "nginx", "connections_total"),
+			"Total number of connections with a given state",
+			[]string{"state"}, constLabels),
+		requestsTotal: prometheus.NewDesc(
+			prometheus.BuildFQName(PrometheusNamespace, "nginx", "requests_total"),
+			"Total number of client requests",
+			nil, constLabels),
+		connections: prometheus.NewDesc(
+			prometheus.BuildFQName(PrometheusNamespace, "nginx", "connections"),
+			"Current number of client connections with a given state",
+			[]string{"state"}, constLabels),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *nginxStatusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.connectionsTotal
+	ch <- c.requestsTotal
+	ch <- c.connections
+}
+
+// Collect implements prometheus.Collector
+func (c *nginxStatusCollector) Collect(ch chan<- prometheus.Metric) {
+	s, err := c.scrape()
+	if err != nil {
+		glog.Warningf("unexpected error obtaining NGINX status info: %v", err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.connectionsTotal, prometheus.CounterValue, float64(s.Accepted), "accepted")
+	ch <- prometheus.MustNewConstMetric(c.connectionsTotal, prometheus.CounterValue, float64(s.Handled), "handled")
+	ch <- prometheus.MustNewConstMetric(c.requestsTotal, prometheus.CounterValue, float64(s.Requests))
+	ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(s.Active), "active")
+	ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(s.Reading), "reading")
+	ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(s.Writing), "writing")
+	ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(s.Waiting), "waiting")
+}
+
+func (c *nginxStatusCollector) scrape() (*nginxStatus, error) {
+	resp, err := c.client.Get(c.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("unexpected status code %v from %v", resp.StatusCode, c.url)
+	}
+
+	return parse(string(data)), nil
+}
+
+// parse converts the output of the stub_status module:
+//
+//	Active connections: 291
+//	server accepts handled requests
+//	 16630948 16630948 31070465
+//	Reading: 6 Writing: 179 Waiting: 106
+func parse(data string) *nginxStatus {
+	acr := ac.FindStringSubmatch(data)
+	sahrr := sahr.FindStringSubmatch(data)
+	readingr := reading.FindStringSubmatch(data)
+	writingr := writing.FindStringSubmatch(data)
+	waitingr := waiting.FindStringSubmatch(data)
+
+	return &nginxStatus{
+		Active:   toInt(acr, 1),
+		Accepted: toInt(sahrr, 1),
+		Handled:  toInt(sahrr, 2),
+		Requests: toInt(sahrr, 3),
+		Reading:  toInt(readingr, 1),
+		Writing:  toInt(writingr, 1),
+		Waiting:  toInt(waitingr, 1),
+	}
+}
+
+func toInt(data []string, pos int) int {
+	if len(data) == 0 {
+		return 0
+	}
+	if pos > len(data)-1 {
+		return 0
+	}
+	if v, err := strconv.Atoi(data[pos]); err == nil {
+		return v
+	}
+	return 0
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package metric
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const stubStatus = `Active connections: 291 
+server accepts handled requests
+ 16630948 16630947 31070465 
+Reading: 6 Writing: 179 Waiting: 106 
+`
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		in       string
+		expected *nginxStatus
+	}{
+		{"empty", "", &nginxStatus{}},
+		{"stub status", stubStatus, &nginxStatus{291, 16630948, 16630947, 31070465, 6, 179, 106}},
+	}
+
+	for _, tc := range testCases {
+		r := parse(tc.in)
+		if !reflect.DeepEqual(r, tc.expected) {
+			t.Errorf("%v: expected %v but returned %v", tc.name, tc.expected, r)
+		}
+	}
+}
+
+func TestNGINXStatusCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, stubStatus)
+	}))
+	defer server.Close()
+
+	c := newNGINXStatusCollector(server.URL, prometheus.Labels{"controller_class": "test"})
+
+	expected := `
+		# HELP management_ingress_nginx_requests_total Total number of client requests
+		# TYPE management_ingress_nginx_requests_total counter
+		management_ingress_nginx_requests_total{controller_class="test"} 3.1070465e+07
+		# HELP management_ingress_nginx_connections Current number of client connections with a given state
+		# TYPE management_ingress_nginx_connections gauge
+		management_ingress_nginx_connections{controller_class="test",state="active"} 291
+		management_ingress_nginx_connections{controller_class="test",state="reading"} 6
+		management_ingress_nginx_connections{controller_class="test",state="waiting"} 106
+		management_ingress_nginx_connections{controller_class="test",state="writing"} 179
+	`
+	err := testutil.CollectAndCompare(c, strings.NewReader(expected),
+		"management_ingress_nginx_requests_total", "management_ingress_nginx_connections")
+	if err != nil {
+		t.Errorf("unexpected error collecting NGINX status: %v", err)
+	}
+
+	server.Close()
+	if n := testutil.CollectAndCount(c); n != 0 {
+		t.Errorf("expected no metrics when NGINX is not available but returned %v", n)
+	}
+}
@@ -69,6 +69,9 @@ type Config struct {
 
 	DefaultIngressClass string
 	IngressClass        string
+
+	// OnLeaderChange is invoked when this instance acquires or loses the leadership
+	OnLeaderChange func(isLeader bool)
 }
 
 // statusSync keeps the status IP in each Ingress rule updated executing a periodic check
@@ -168,6 +171,9 @@ func NewStatusSyncer(config Config) Sync {
 	callbacks := leaderelection.LeaderCallbacks{
 		OnStartedLeading: func(ctx context.Context) {
 			glog.V(2).Infof("I am the new status update leader")
+			if config.OnLeaderChange != nil {
+				config.OnLeaderChange(true)
+			}
 			stopCh = make(chan struct{})
 			go st.syncQueue.Run(time.Second, stopCh)
 			err = wait.PollUntil(updateInterval, func() (bool, error) {
@@ -181,6 +187,9 @@ func NewStatusSyncer(config Config) Sync {
 		},
 		OnStoppedLeading: func() {
 			glog.V(2).Infof("I am not status update leader anymore")
+			if config.OnLeaderChange != nil {
+				config.OnLeaderChange(false)
+			}
 		},
 		OnNewLeader: func(identity string) {
 			glog.Infof("new leader elected: %v", identity)
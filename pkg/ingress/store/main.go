@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
 	"k8s.io/client-go/tools/cache"
 )
 
@@ -28,6 +29,23 @@ type IngressLister struct {
 	cache.Store
 }
 
+// IngressClassLister makes a Store that lists IngressClasses.
+type IngressClassLister struct {
+	cache.Store
+}
+
+// GetByName searches for an IngressClass in the local IngressClasses Store
+func (icl *IngressClassLister) GetByName(name string) (*networking.IngressClass, error) {
+	s, exists, err := icl.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("ingressclass %v was not found", name)
+	}
+	return s.(*networking.IngressClass), nil
+}
+
 // IngressAnnotationsLister makes a Store that lists annotations in Ingress rules.
 type IngressAnnotationsLister struct {
 	cache.Store
@@ -18,9 +18,17 @@ import (
 	networking "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/authreq"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/authtls"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/canary"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/compression"
 	"github.com/stolostron/management-ingress/pkg/ingress/annotations/connection"
 	"github.com/stolostron/management-ingress/pkg/ingress/annotations/proxy"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/ratelimit"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/redirect"
 	"github.com/stolostron/management-ingress/pkg/ingress/annotations/rewrite"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/sessionaffinity"
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/sourcerange"
 	"github.com/stolostron/management-ingress/pkg/ingress/resolver"
 	"github.com/stolostron/management-ingress/pkg/ingress/store"
 )
@@ -44,6 +52,7 @@ const (
 // endpoints, secrets and configmaps.
 type StoreLister struct {
 	Ingress           store.IngressLister
+	IngressClass      store.IngressClassLister
 	Service           store.ServiceLister
 	Endpoint          store.EndpointLister
 	Secret            store.SecretLister
@@ -69,11 +78,18 @@ type Backend struct {
 	Service   *apiv1.Service     `json:"service,omitempty"`
 	Port      intstr.IntOrString `json:"port"`
 	ClusterIP string             `json:"clusterIP"`
+	// ExternalName is the DNS name of a service of type ExternalName. These
+	// backends do not have endpoints, the name is resolved by NGINX using
+	// the configured resolver.
+	ExternalName string `json:"externalName,omitempty"`
 	// This indicates if the communication protocol between the backend and the endpoint is HTTP or HTTPS
 	// Allowing the use of HTTPS
 	// The endpoint/s must provide a TLS connection.
 	// The certificate used in the endpoint cannot be a self signed certificate
 	Secure bool `json:"secure"`
+	// BackendProtocol is the protocol of the endpoints: HTTP, HTTPS, GRPC,
+	// GRPCS or H2C. Empty is HTTP, or HTTPS when the backend is secure
+	BackendProtocol string `json:"backendProtocol,omitempty"`
 	// SecureCACert has the filename and SHA1 of the certificate authorities used to validate
 	// a secured connection to the backend
 	SecureCACert resolver.AuthSSLCert `json:"secureCACert"`
@@ -81,12 +97,38 @@ type Backend struct {
 	ClientCACert resolver.AuthSSLCert `json:"clientCACert"`
 	// Consistent hashing by NGINX variable
 	UpstreamHashBy string `json:"upstream-hash-by,omitempty"`
+	// SessionAffinity routes the requests of a session to the same endpoint,
+	// it takes precedence over UpstreamHashBy
+	SessionAffinity sessionaffinity.Config `json:"sessionAffinity,omitempty"`
+	// Endpoints contains the list of pod addresses behind the service
+	Endpoints []Endpoint `json:"endpoints,omitempty"`
+}
+
+// DynamicEndpoints returns true if the endpoints of the backend can be updated
+// in the Lua balancer without reloading NGINX. Backends that use consistent
+// hashing are balanced by NGINX and always require a reload. Backends of
+// ExternalName services do not have endpoints.
+func (b *Backend) DynamicEndpoints() bool {
+	return b.UpstreamHashBy == "" && b.ExternalName == ""
+}
+
+// Endpoint describes a pod address that serves traffic for a Backend
+type Endpoint struct {
+	// Address IP address of the endpoint
+	Address string `json:"address"`
+	// Port number of the TCP port
+	Port string `json:"port"`
+	// Ready indicates if the endpoint passed its readiness checks
+	Ready bool `json:"ready"`
 }
 
 // Server describes a website
 type Server struct {
 	// Hostname returns the FQDN of the server
 	Hostname string `json:"hostname"`
+	// Namespace is the namespace of the oldest Ingress that uses the host,
+	// which owns the server. It is empty in the default server.
+	Namespace string `json:"namespace,omitempty"`
 	// Locations list of URIs configured in the server.
 	Locations []*Location `json:"locations,omitempty"`
 	// SSLCertificate path to the SSL certificate on disk
@@ -103,6 +145,37 @@ type Server struct {
 	SSLPemChecksum string `json:"sslPemChecksum"`
 	// Alias return the alias of the server name
 	Alias string `json:"alias,omitempty"`
+	// CertificateAuth indicates the clients of the server are
+	// authenticated with certificates. The oldest Ingress that
+	// uses the host and defines it is used
+	// +optional
+	CertificateAuth authtls.Config `json:"certificateAuth,omitempty"`
+}
+
+// ErrorPages describes the error pages of a location. They are returned by
+// the upstream of a service or read from a ConfigMap.
+type ErrorPages struct {
+	// Codes are the status codes of the upstream responses that are replaced
+	Codes []int `json:"codes,omitempty"`
+	// Backend is the name of the upstream that returns the error pages
+	// +optional
+	Backend string `json:"backend,omitempty"`
+	// ConfigMap is the namespace/name of the ConfigMap that contains the error pages
+	// +optional
+	ConfigMap string `json:"configMap,omitempty"`
+	// Pages contains the HTML of the error pages of the ConfigMap by status code
+	// +optional
+	Pages map[int]string `json:"pages,omitempty"`
+}
+
+// SecurityHeaders describes the security headers added to the responses
+// of a location. The headers with an empty value are not added.
+type SecurityHeaders struct {
+	XFrameOptions           string `json:"xFrameOptions,omitempty"`
+	XContentTypeOptions     string `json:"xContentTypeOptions,omitempty"`
+	XXSSProtection          string `json:"xXSSProtection,omitempty"`
+	ContentSecurityPolicy   string `json:"contentSecurityPolicy,omitempty"`
+	StrictTransportSecurity string `json:"strictTransportSecurity,omitempty"`
 }
 
 // Location describes an URI inside a server.
@@ -125,6 +198,9 @@ type Location struct {
 	// a '/'. If unspecified, the path defaults to a catch all sending
 	// traffic to the backend.
 	Path string `json:"path"`
+	// PathType indicates how the path is matched. An empty value is
+	// handled like ImplementationSpecific
+	PathType networking.PathType `json:"pathType,omitempty"`
 	// Ingress returns the ingress from which this location was generated
 	Ingress *networking.Ingress `json:"ingress"`
 	// Backend describes the name of the backend to use.
@@ -143,6 +219,17 @@ type Location struct {
 	// Rewrite describes the redirection this location.
 	// +optional
 	Rewrite rewrite.Config `json:"rewrite,omitempty"`
+	// Redirect describes the redirection of the requests of the location to an URL
+	// +optional
+	Redirect redirect.Config `json:"redirect,omitempty"`
+	// SSLRedirect indicates the HTTP requests are redirected to HTTPS
+	// when the server has a certificate
+	// +optional
+	SSLRedirect bool `json:"sslRedirect,omitempty"`
+	// ForceSSLRedirect indicates the HTTP requests are redirected to
+	// HTTPS even when the server does not have a certificate
+	// +optional
+	ForceSSLRedirect bool `json:"forceSSLRedirect,omitempty"`
 	// XForwardedPrefix allows to add a header X-Forwarded-Prefix to the request with the
 	// original location.
 	// +optional
@@ -151,6 +238,14 @@ type Location struct {
 	AuthType string `json:"authType,omitempty"`
 	// AuthzType indicates the authorization method used in the location
 	AuthzType string `json:"authzType,omitempty"`
+	// ExternalAuth indicates the access to this location requires
+	// the authentication of an external service
+	// +optional
+	ExternalAuth authreq.Config `json:"externalAuth,omitempty"`
+	// CertificateAuth indicates the error page and the headers of the
+	// client certificate authentication of the location
+	// +optional
+	CertificateAuth authtls.Config `json:"certificateAuth,omitempty"`
 	// Location Modifier indicates the location match operator
 	LocationModifier string `json:"locationModifier,omitempty"`
 	// Upstream uri gives the additional uri to the current path of location
@@ -159,4 +254,35 @@ type Location struct {
 	// to be used in connections against endpoints
 	// +optional
 	Proxy proxy.Config `json:"proxy,omitempty"`
+	// RateLimit describes the limits of connections and requests of each client
+	// +optional
+	RateLimit ratelimit.Config `json:"rateLimit,omitempty"`
+	// SourceRange describes the clients allowed and denied to access the location.
+	// The source ranges of the configuration are used when it is empty
+	// +optional
+	SourceRange sourcerange.Config `json:"sourceRange,omitempty"`
+	// ErrorPages describes the pages returned instead of the upstream
+	// responses with an error status code
+	// +optional
+	ErrorPages ErrorPages `json:"errorPages,omitempty"`
+	// Compression describes the compression of the responses when
+	// it differs from the one of the configuration
+	// +optional
+	Compression compression.Config `json:"compression,omitempty"`
+	// ProxySetHeaders contains the headers sent to the upstream, read from
+	// the ConfigMap of the proxy-set-headers annotation of the Ingress
+	// +optional
+	ProxySetHeaders map[string]string `json:"proxySetHeaders,omitempty"`
+	// SecurityHeaders describes the security headers added to the responses,
+	// the ones of the configuration overridden by the annotations of the Ingress
+	// +optional
+	SecurityHeaders SecurityHeaders `json:"securityHeaders,omitempty"`
+	// CanaryBackend is the name of the backend of a canary Ingress that
+	// receives part of the traffic of the location
+	// +optional
+	CanaryBackend string `json:"canaryBackend,omitempty"`
+	// Canary describes how the traffic is split between the
+	// backend and the canary backend
+	// +optional
+	Canary canary.Config `json:"canary,omitempty"`
 }
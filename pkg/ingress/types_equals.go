@@ -18,6 +18,18 @@ package ingress
 
 // Equal tests for equality between two Configuration types
 func (c1 *Configuration) Equal(c2 *Configuration) bool {
+	return c1.equal(c2, false)
+}
+
+// EqualWithoutEndpoints tests for equality between two Configuration types
+// ignoring the endpoints of the backends that support dynamic endpoints.
+// Two configurations that only differ in those endpoints generate the same
+// NGINX configuration structure and do not require a reload.
+func (c1 *Configuration) EqualWithoutEndpoints(c2 *Configuration) bool {
+	return c1.equal(c2, true)
+}
+
+func (c1 *Configuration) equal(c2 *Configuration, ignoreEndpoints bool) bool {
 	if c1 == c2 {
 		return true
 	}
@@ -32,7 +44,7 @@ func (c1 *Configuration) Equal(c2 *Configuration) bool {
 	for _, c1b := range c1.Backends {
 		found := false
 		for _, c2b := range c2.Backends {
-			if c1b.Equal(c2b) {
+			if c1b.equal(c2b, ignoreEndpoints) {
 				found = true
 				break
 			}
@@ -58,6 +70,10 @@ func (c1 *Configuration) Equal(c2 *Configuration) bool {
 
 // Equal tests for equality between two Backend types
 func (b1 *Backend) Equal(b2 *Backend) bool {
+	return b1.equal(b2, false)
+}
+
+func (b1 *Backend) equal(b2 *Backend, ignoreEndpoints bool) bool {
 	if b1 == b2 {
 		return true
 	}
@@ -89,6 +105,9 @@ func (b1 *Backend) Equal(b2 *Backend) bool {
 	if b1.Secure != b2.Secure {
 		return false
 	}
+	if b1.BackendProtocol != b2.BackendProtocol {
+		return false
+	}
 	if !(&b1.SecureCACert).Equal(&b2.SecureCACert) {
 		return false
 	}
@@ -98,9 +117,51 @@ func (b1 *Backend) Equal(b2 *Backend) bool {
 	if b1.UpstreamHashBy != b2.UpstreamHashBy {
 		return false
 	}
+	if !(&b1.SessionAffinity).Equal(&b2.SessionAffinity) {
+		return false
+	}
 	if b1.ClusterIP != b2.ClusterIP {
 		return false
 	}
+	if b1.ExternalName != b2.ExternalName {
+		return false
+	}
+
+	if ignoreEndpoints && b1.DynamicEndpoints() {
+		return true
+	}
+
+	if len(b1.Endpoints) != len(b2.Endpoints) {
+		return false
+	}
+
+	// Endpoints are sorted
+	for idx, b1e := range b1.Endpoints {
+		if !(&b1e).Equal(&b2.Endpoints[idx]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Equal tests for equality between two Endpoint types
+func (e1 *Endpoint) Equal(e2 *Endpoint) bool {
+	if e1 == e2 {
+		return true
+	}
+	if e1 == nil || e2 == nil {
+		return false
+	}
+	if e1.Address != e2.Address {
+		return false
+	}
+	if e1.Port != e2.Port {
+		return false
+	}
+	if e1.Ready != e2.Ready {
+		return false
+	}
 
 	return true
 }
@@ -128,6 +189,9 @@ func (s1 *Server) Equal(s2 *Server) bool {
 	if s1.SSLFullChainCertificate != s2.SSLFullChainCertificate {
 		return false
 	}
+	if !(&s1.CertificateAuth).Equal(&s2.CertificateAuth) {
+		return false
+	}
 
 	if len(s1.Locations) != len(s2.Locations) {
 		return false
@@ -154,6 +218,9 @@ func (l1 *Location) Equal(l2 *Location) bool {
 	if l1.Path != l2.Path {
 		return false
 	}
+	if l1.PathType != l2.PathType {
+		return false
+	}
 	if l1.Backend != l2.Backend {
 		return false
 	}
@@ -179,6 +246,15 @@ func (l1 *Location) Equal(l2 *Location) bool {
 	if !(&l1.Rewrite).Equal(&l2.Rewrite) {
 		return false
 	}
+	if !(&l1.Redirect).Equal(&l2.Redirect) {
+		return false
+	}
+	if l1.SSLRedirect != l2.SSLRedirect {
+		return false
+	}
+	if l1.ForceSSLRedirect != l2.ForceSSLRedirect {
+		return false
+	}
 	if l1.ConfigurationSnippet != l2.ConfigurationSnippet {
 		return false
 	}
@@ -191,6 +267,12 @@ func (l1 *Location) Equal(l2 *Location) bool {
 	if l1.AuthzType != l2.AuthzType {
 		return false
 	}
+	if !(&l1.ExternalAuth).Equal(&l2.ExternalAuth) {
+		return false
+	}
+	if !(&l1.CertificateAuth).Equal(&l2.CertificateAuth) {
+		return false
+	}
 	if l1.LocationModifier != l2.LocationModifier {
 		return false
 	}
@@ -203,6 +285,69 @@ func (l1 *Location) Equal(l2 *Location) bool {
 	if !(&l1.Connection).Equal(&l2.Connection) {
 		return false
 	}
+	if !(&l1.RateLimit).Equal(&l2.RateLimit) {
+		return false
+	}
+	if !(&l1.SourceRange).Equal(&l2.SourceRange) {
+		return false
+	}
+	if !(&l1.ErrorPages).Equal(&l2.ErrorPages) {
+		return false
+	}
+	if !(&l1.Compression).Equal(&l2.Compression) {
+		return false
+	}
+	if l1.SecurityHeaders != l2.SecurityHeaders {
+		return false
+	}
+	if len(l1.ProxySetHeaders) != len(l2.ProxySetHeaders) {
+		return false
+	}
+	for name, value := range l1.ProxySetHeaders {
+		if value2, ok := l2.ProxySetHeaders[name]; !ok || value != value2 {
+			return false
+		}
+	}
+	if l1.CanaryBackend != l2.CanaryBackend {
+		return false
+	}
+	if !(&l1.Canary).Equal(&l2.Canary) {
+		return false
+	}
+
+	return true
+}
+
+// Equal tests for equality between two ErrorPages types
+func (e1 *ErrorPages) Equal(e2 *ErrorPages) bool {
+	if e1 == e2 {
+		return true
+	}
+	if e1 == nil || e2 == nil {
+		return false
+	}
+	if e1.Backend != e2.Backend {
+		return false
+	}
+	if e1.ConfigMap != e2.ConfigMap {
+		return false
+	}
+	if len(e1.Codes) != len(e2.Codes) {
+		return false
+	}
+	for idx, code := range e1.Codes {
+		if code != e2.Codes[idx] {
+			return false
+		}
+	}
+	if len(e1.Pages) != len(e2.Pages) {
+		return false
+	}
+	for code, page := range e1.Pages {
+		if page2, ok := e2.Pages[code]; !ok || page != page2 {
+			return false
+		}
+	}
 
 	return true
 }
@@ -0,0 +1,83 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package ingress
+
+import (
+	"testing"
+
+	"github.com/stolostron/management-ingress/pkg/ingress/annotations/compression"
+)
+
+func TestConfigurationEqualWithoutEndpoints(t *testing.T) {
+	newConfig := func(hashBy string, endpoints ...Endpoint) *Configuration {
+		return &Configuration{
+			Backends: []*Backend{
+				{Name: "default-web-80", UpstreamHashBy: hashBy, Endpoints: endpoints},
+			},
+			Servers: []*Server{
+				{Hostname: "_", Locations: []*Location{{Path: "/", Backend: "default-web-80"}}},
+			},
+		}
+	}
+
+	ep1 := Endpoint{Address: "10.1.0.1", Port: "8080", Ready: true}
+	ep2 := Endpoint{Address: "10.1.0.2", Port: "8080", Ready: true}
+
+	tests := []struct {
+		name           string
+		c1             *Configuration
+		c2             *Configuration
+		equal          bool
+		equalEndpoints bool
+	}{
+		{"same configuration", newConfig("", ep1), newConfig("", ep1), true, true},
+		{"endpoints changed", newConfig("", ep1), newConfig("", ep1, ep2), false, true},
+		{"endpoints changed with hash", newConfig("$request_uri", ep1), newConfig("$request_uri", ep2), false, false},
+		{"backend changed", newConfig("", ep1), newConfig("$request_uri", ep1), false, false},
+		{"location changed", newConfig("", ep1), func() *Configuration {
+			c := newConfig("", ep2)
+			c.Servers[0].Locations[0].Path = "/web"
+			return c
+		}(), false, false},
+		{"rate limit changed", newConfig("", ep1), func() *Configuration {
+			c := newConfig("", ep1)
+			c.Servers[0].Locations[0].RateLimit.RPS.Limit = 10
+			return c
+		}(), false, false},
+		{"client certificate authentication changed", newConfig("", ep1), func() *Configuration {
+			c := newConfig("", ep1)
+			c.Servers[0].CertificateAuth.VerifyClient = "optional"
+			return c
+		}(), false, false},
+		{"error pages changed", newConfig("", ep1), func() *Configuration {
+			c := newConfig("", ep1)
+			c.Servers[0].Locations[0].ErrorPages = ErrorPages{ConfigMap: "default/pages", Codes: []int{404}, Pages: map[int]string{404: "not found"}}
+			return c
+		}(), false, false},
+		{"compression changed", newConfig("", ep1), func() *Configuration {
+			c := newConfig("", ep1)
+			c.Servers[0].Locations[0].Compression = compression.Config{Types: "text/css"}
+			return c
+		}(), false, false},
+		{"proxy set headers changed", newConfig("", ep1), func() *Configuration {
+			c := newConfig("", ep1)
+			c.Servers[0].Locations[0].ProxySetHeaders = map[string]string{"X-Tenant": "a"}
+			return c
+		}(), false, false},
+		{"security headers changed", newConfig("", ep1), func() *Configuration {
+			c := newConfig("", ep1)
+			c.Servers[0].Locations[0].SecurityHeaders = SecurityHeaders{ContentSecurityPolicy: "frame-ancestors 'self'"}
+			return c
+		}(), false, false},
+	}
+
+	for _, test := range tests {
+		if eq := test.c1.Equal(test.c2); eq != test.equal {
+			t.Errorf("%v: expected Equal to return %v but got %v", test.name, test.equal, eq)
+		}
+		if eq := test.c1.EqualWithoutEndpoints(test.c2); eq != test.equalEndpoints {
+			t.Errorf("%v: expected EqualWithoutEndpoints to return %v but got %v", test.name, test.equalEndpoints, eq)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+local cjson = require "cjson.safe"
+local ngx_balancer = require "ngx.balancer"
+local resty_string = require "resty.string"
+local configuration = require "configuration"
+
+-- the servers of the upstream blocks are used by the session affinity
+-- when the endpoints are not configured dynamically
+local has_ngx_upstream, ngx_upstream = pcall(require, "ngx.upstream")
+
+-- interval in seconds used to check for new backends in the shared dict
+local sync_interval = 1
+
+-- endpoints of each backend indexed by the upstream name, and the version
+-- of the configuration they were decoded from (per worker)
+local backends = {}
+local backends_version = nil
+local round_robin_index = {}
+
+local function sync_backends()
+    local data, version = configuration.get_backends()
+    if not data or version == backends_version then
+        return
+    end
+
+    local new_backends, err = cjson.decode(data)
+    if type(new_backends) ~= "table" then
+        ngx.log(ngx.ERR, "could not decode the backends configuration: ", err)
+        return
+    end
+
+    local endpoints = {}
+    for _, backend in ipairs(new_backends) do
+        if type(backend.endpoints) == "table" then
+            endpoints[backend.name] = backend.endpoints
+        end
+    end
+
+    backends = endpoints
+    backends_version = version
+    round_robin_index = {}
+end
+
+local function init_worker()
+    sync_backends()
+    local ok, err = ngx.timer.every(sync_interval, sync_backends)
+    if not ok then
+        ngx.log(ngx.ERR, "error creating the backends sync timer: ", err)
+    end
+end
+
+-- endpoint_hash returns the value of the affinity cookie of an endpoint
+local function endpoint_hash(address, hash)
+    if hash == "sha1" then
+        return resty_string.to_hex(ngx.sha1_bin(address))
+    end
+    return ngx.md5(address)
+end
+
+-- endpoint_address returns the address of an endpoint with the format of
+-- the variable $upstream_addr, using brackets in IPv6 addresses
+local function endpoint_address(endpoint)
+    if string.find(endpoint.address, ":", 1, true) then
+        return "[" .. endpoint.address .. "]:" .. endpoint.port
+    end
+    return endpoint.address .. ":" .. endpoint.port
+end
+
+-- sticky_endpoint returns the address and port of the endpoint of a backend
+-- whose hash is the value of the affinity cookie of the request
+local function sticky_endpoint(name, cookie_name, hash)
+    local cookie = ngx.var["cookie_" .. cookie_name]
+    if not cookie or cookie == "" then
+        return nil
+    end
+
+    local endpoints = backends[name]
+    if endpoints and #endpoints > 0 then
+        for _, endpoint in ipairs(endpoints) do
+            if endpoint_hash(endpoint_address(endpoint), hash) == cookie then
+                return endpoint.address, tonumber(endpoint.port)
+            end
+        end
+        return nil
+    end
+
+    if not has_ngx_upstream then
+        return nil
+    end
+    local peers = ngx_upstream.get_primary_peers(name)
+    if not peers then
+        return nil
+    end
+    for _, peer in ipairs(peers) do
+        if not peer.down and endpoint_hash(peer.name, hash) == cookie then
+            local address, port = string.match(peer.name, "^%[?(.-)%]?:(%d+)$")
+            return address, tonumber(port)
+        end
+    end
+
+    return nil
+end
+
+-- balance selects the endpoint of the current request using round robin.
+-- When the backend has no endpoints the servers of the upstream block,
+-- rendered in the configuration, are used. With the name and hash of an
+-- affinity cookie, the endpoint of the cookie is used when it exists.
+local function balance(cookie_name, hash)
+    local name = ngx.var.proxy_upstream_name
+
+    if cookie_name then
+        local address, port = sticky_endpoint(name, cookie_name, hash)
+        if address then
+            local ok, err = ngx_balancer.set_current_peer(address, port)
+            if ok then
+                return
+            end
+            ngx.log(ngx.ERR, "error setting the affinity endpoint ", address, ":", port,
+                " of backend ", name, ": ", err)
+        end
+    end
+
+    local endpoints = backends[name]
+    if not endpoints or #endpoints == 0 then
+        return
+    end
+
+    local index = (round_robin_index[name] or 0) % #endpoints + 1
+    round_robin_index[name] = index
+
+    local endpoint = endpoints[index]
+    local ok, err = ngx_balancer.set_current_peer(endpoint.address, tonumber(endpoint.port))
+    if not ok then
+        ngx.log(ngx.ERR, "error setting the endpoint ", endpoint.address, ":", endpoint.port,
+            " of backend ", name, ": ", err)
+        return ngx.exit(ngx.ERROR)
+    end
+end
+
+-- set_affinity_cookie sets the affinity cookie of the backend of the request
+-- when the request does not contain the cookie of the selected endpoint. The
+-- cookies are indexed by the name of the backend, which is only known after
+-- the request is proxied.
+local function set_affinity_cookie(cookies)
+    local cookie = cookies[ngx.var.proxy_upstream_name]
+    local upstream_addr = ngx.var.upstream_addr
+    if not cookie or not upstream_addr then
+        return
+    end
+
+    -- the last address is the one of the response after retries and redirects
+    local address = string.match(upstream_addr, "(%[[^%]]+%]:%d+)$") or
+        string.match(upstream_addr, "([^%s,:]+:%d+)$")
+    if not address then
+        return
+    end
+
+    local value = endpoint_hash(address, cookie.hash)
+    if ngx.var["cookie_" .. cookie.name] == value then
+        return
+    end
+
+    local attributes = { cookie.name .. "=" .. value, "Path=" .. cookie.path, "HttpOnly" }
+    if cookie.expires and cookie.expires > 0 then
+        table.insert(attributes, "Expires=" .. ngx.cookie_time(ngx.time() + cookie.expires))
+    end
+    if cookie.max_age and cookie.max_age > 0 then
+        table.insert(attributes, "Max-Age=" .. cookie.max_age)
+    end
+    if cookie.samesite and cookie.samesite ~= "" then
+        table.insert(attributes, "SameSite=" .. cookie.samesite)
+    end
+    if cookie.secure then
+        table.insert(attributes, "Secure")
+    end
+
+    local header = table.concat(attributes, "; ")
+    local set_cookie = ngx.header["Set-Cookie"]
+    if not set_cookie then
+        ngx.header["Set-Cookie"] = header
+    elseif type(set_cookie) == "table" then
+        table.insert(set_cookie, header)
+        ngx.header["Set-Cookie"] = set_cookie
+    else
+        ngx.header["Set-Cookie"] = { set_cookie, header }
+    end
+end
+
+-- Expose interface.
+local _M = {}
+_M.init_worker = init_worker
+_M.balance = balance
+_M.set_affinity_cookie = set_affinity_cookie
+
+return _M
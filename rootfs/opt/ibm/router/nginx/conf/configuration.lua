@@ -0,0 +1,92 @@
+local cjson = require "cjson.safe"
+
+-- The ingress controller sends the endpoints of the backends as a JSON array
+-- of {"name": "<upstream>", "endpoints": [{"address", "port", "ready"}]}.
+-- The content is stored in a shared dict so it is available in all the
+-- workers and survives the reloads of NGINX.
+local configuration_data = ngx.shared.configuration_data
+
+local function get_backends()
+    return configuration_data:get("backends"), configuration_data:get("backends_version")
+end
+
+local function read_body()
+    ngx.req.read_body()
+    local body = ngx.req.get_body_data()
+    if not body then
+        -- the body is written to a file when it exceeds the buffer size
+        local path = ngx.req.get_body_file()
+        if path then
+            local f, err = io.open(path, "rb")
+            if not f then
+                ngx.log(ngx.ERR, "error reading request body file: " .. err)
+                return nil
+            end
+            body = f:read("*a")
+            f:close()
+        end
+    end
+    return body
+end
+
+local function set_backends()
+    local body = read_body()
+    if not body then
+        ngx.status = ngx.HTTP_BAD_REQUEST
+        ngx.say("missing request body")
+        return
+    end
+
+    local backends, err = cjson.decode(body)
+    if type(backends) ~= "table" then
+        ngx.log(ngx.ERR, "invalid backends configuration: ", err)
+        ngx.status = ngx.HTTP_BAD_REQUEST
+        ngx.say("invalid backends configuration")
+        return
+    end
+
+    local ok, err = configuration_data:set("backends", body)
+    if not ok then
+        ngx.log(ngx.ERR, "error storing the backends configuration: ", err)
+        ngx.status = ngx.HTTP_INTERNAL_SERVER_ERROR
+        return
+    end
+    configuration_data:incr("backends_version", 1, 0)
+
+    ngx.status = ngx.HTTP_CREATED
+end
+
+local function call()
+    if ngx.var.uri ~= "/configuration/backends" then
+        ngx.status = ngx.HTTP_NOT_FOUND
+        ngx.say("not found")
+        return
+    end
+
+    local method = ngx.req.get_method()
+    if method == "GET" then
+        local backends = get_backends()
+        if not backends then
+            ngx.status = ngx.HTTP_NOT_FOUND
+            ngx.say("backends not configured")
+            return
+        end
+        ngx.header["Content-Type"] = "application/json"
+        ngx.print(backends)
+        return
+    end
+
+    if method == "POST" then
+        return set_backends()
+    end
+
+    ngx.status = ngx.HTTP_NOT_ALLOWED
+    ngx.say("only GET and POST requests are allowed")
+end
+
+-- Expose interface.
+local _M = {}
+_M.get_backends = get_backends
+_M.call = call
+
+return _M